@@ -0,0 +1,225 @@
+package trustednode
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils"
+	"github.com/rocket-pool/rocketpool-go/utils/validator"
+)
+
+// Settings
+const scrubCheckThreadLimit int = 6
+
+// Why a minipool should be scrubbed
+type ScrubReason string
+
+const (
+	// The minipool's prestake deposit didn't use the minipool's withdrawal credentials
+	ScrubReason_PrestakeCredentials ScrubReason = "prestakeCredentials"
+
+	// The validator's first valid deposit on the Beacon deposit contract used other withdrawal credentials
+	ScrubReason_ConflictingDeposit ScrubReason = "conflictingDeposit"
+)
+
+// A problem found with a prelaunch minipool's deposits
+type ScrubFinding struct {
+	Reason                ScrubReason `json:"reason"`
+	WithdrawalCredentials common.Hash `json:"withdrawalCredentials"` // The credentials that were used
+	TxHash                common.Hash `json:"txHash"`                // The offending Beacon deposit, if there is one
+	BlockNumber           uint64      `json:"blockNumber"`
+}
+
+// A prelaunch minipool to check
+type ScrubCandidate struct {
+	MinipoolAddress               common.Address        `json:"minipoolAddress"`
+	Pubkey                        types.ValidatorPubkey `json:"pubkey"`
+	ExpectedWithdrawalCredentials common.Hash           `json:"expectedWithdrawalCredentials"`
+	Vacant                        bool                  `json:"vacant"`
+	Prestake                      minipool.PrestakeData `json:"prestake"` // Empty for vacant minipools
+}
+
+// The result of checking a prelaunch minipool
+type ScrubCheck struct {
+	Candidate ScrubCandidate `json:"candidate"`
+	Checked   bool           `json:"checked"` // False if there were no valid Beacon deposits for the validator yet, or the minipool is vacant
+	Findings  []ScrubFinding `json:"findings"`
+}
+
+// Check if the minipool should be scrubbed
+func (c ScrubCheck) ShouldScrub() bool {
+	return len(c.Findings) > 0
+}
+
+// Load every prelaunch minipool with its validator pubkey and prestake event
+func GetScrubCandidates(rp *rocketpool.RocketPool, intervalSize *big.Int, opts *bind.CallOpts) ([]ScrubCandidate, error) {
+	addresses, err := minipool.GetPrelaunchMinipoolAddresses(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]ScrubCandidate, len(addresses))
+	var wg errgroup.Group
+	wg.SetLimit(scrubCheckThreadLimit)
+	for i, address := range addresses {
+		i, address := i, address
+		wg.Go(func() error {
+			mp, err := minipool.NewMinipool(rp, address, opts)
+			if err != nil {
+				return err
+			}
+			statusDetails, err := mp.GetStatusDetails(opts)
+			if err != nil {
+				return err
+			}
+			pubkey, err := minipool.GetMinipoolPubkey(rp, address, opts)
+			if err != nil {
+				return err
+			}
+			candidate := ScrubCandidate{
+				MinipoolAddress:               address,
+				Pubkey:                        pubkey,
+				ExpectedWithdrawalCredentials: validator.GetMinipoolWithdrawalCredentials(address),
+				Vacant:                        statusDetails.IsVacant,
+			}
+
+			// Vacant minipools are migrated solo validators, so they were never prestaked
+			if !candidate.Vacant {
+				candidate.Prestake, err = mp.GetPrestakeEvent(intervalSize, opts)
+				if err != nil {
+					return err
+				}
+			}
+			candidates[i] = candidate
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting scrub candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// Get the pubkeys of the candidates, in the form utils.GetDeposits expects
+func GetScrubCandidatePubkeys(candidates []ScrubCandidate) map[types.ValidatorPubkey]bool {
+	pubkeys := make(map[types.ValidatorPubkey]bool, len(candidates))
+	for _, candidate := range candidates {
+		pubkeys[candidate.Pubkey] = true
+	}
+	return pubkeys
+}
+
+// Check the candidates against the Beacon deposit events for their validators (as returned by utils.GetDeposits).
+// Deposits with invalid signatures are ignored, because the Beacon chain ignores them when creating a validator.
+func CheckScrubCandidates(candidates []ScrubCandidate, deposits map[types.ValidatorPubkey][]utils.DepositData, genesisForkVersion [4]byte) []ScrubCheck {
+	checks := make([]ScrubCheck, len(candidates))
+	for i, candidate := range candidates {
+		checks[i] = checkScrubCandidate(candidate, deposits[candidate.Pubkey], genesisForkVersion)
+	}
+	return checks
+}
+
+// Load the prelaunch minipools and the Beacon deposits for them, then check which ones should be scrubbed
+func CheckMinipoolsForScrub(rp *rocketpool.RocketPool, depositStartBlock *big.Int, intervalSize *big.Int, genesisForkVersion [4]byte, opts *bind.CallOpts) ([]ScrubCheck, error) {
+	candidates, err := GetScrubCandidates(rp, intervalSize, opts)
+	if err != nil {
+		return nil, err
+	}
+	deposits, err := utils.GetDeposits(rp, GetScrubCandidatePubkeys(candidates), depositStartBlock, intervalSize, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting Beacon deposits: %w", err)
+	}
+	return CheckScrubCandidates(candidates, deposits, genesisForkVersion), nil
+}
+
+// Estimate the gas of VoteScrubs for each minipool that should be scrubbed
+func EstimateVoteScrubsGas(rp *rocketpool.RocketPool, checks []ScrubCheck, opts *bind.TransactOpts) (map[common.Address]rocketpool.GasInfo, error) {
+	gasInfos := map[common.Address]rocketpool.GasInfo{}
+	for _, check := range checks {
+		if !check.ShouldScrub() {
+			continue
+		}
+		mp, err := minipool.NewMinipool(rp, check.Candidate.MinipoolAddress, nil)
+		if err != nil {
+			return nil, err
+		}
+		gasInfo, err := mp.EstimateVoteScrubGas(opts)
+		if err != nil {
+			return nil, err
+		}
+		gasInfos[check.Candidate.MinipoolAddress] = gasInfo
+	}
+	return gasInfos, nil
+}
+
+// Vote to scrub each minipool that should be scrubbed.
+// If opts has a nonce, it's incremented for each transaction. On error, the hashes of the transactions already sent are returned.
+func VoteScrubs(rp *rocketpool.RocketPool, checks []ScrubCheck, opts *bind.TransactOpts) ([]common.Hash, error) {
+	scrubs := []common.Address{}
+	for _, check := range checks {
+		if check.ShouldScrub() {
+			scrubs = append(scrubs, check.Candidate.MinipoolAddress)
+		}
+	}
+	return rocketpool.SendTransactions(len(scrubs), opts, func(i int, txOpts *bind.TransactOpts) (common.Hash, error) {
+		mp, err := minipool.NewMinipool(rp, scrubs[i], nil)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return mp.VoteScrub(txOpts)
+	})
+}
+
+// Check a single candidate against the Beacon deposits for its validator, which must be sorted by time
+func checkScrubCandidate(candidate ScrubCandidate, deposits []utils.DepositData, genesisForkVersion [4]byte) ScrubCheck {
+	check := ScrubCheck{
+		Candidate: candidate,
+		Findings:  []ScrubFinding{},
+	}
+	if candidate.Vacant {
+		return check
+	}
+
+	// The prestake event is emitted by the minipool itself, so it doesn't need a Beacon deposit to be checked
+	if candidate.Prestake.WithdrawalCredentials != candidate.ExpectedWithdrawalCredentials {
+		check.Findings = append(check.Findings, ScrubFinding{
+			Reason:                ScrubReason_PrestakeCredentials,
+			WithdrawalCredentials: candidate.Prestake.WithdrawalCredentials,
+		})
+	}
+
+	// The first deposit with a valid signature creates the validator and sets its withdrawal credentials
+	for _, deposit := range deposits {
+		err := validator.VerifyDepositSignature(validator.DepositData{
+			Pubkey:                deposit.Pubkey,
+			WithdrawalCredentials: deposit.WithdrawalCredentials,
+			Amount:                deposit.Amount,
+			Signature:             deposit.Signature,
+		}, genesisForkVersion)
+		if err != nil {
+			continue
+		}
+
+		check.Checked = true
+		if deposit.WithdrawalCredentials != candidate.ExpectedWithdrawalCredentials {
+			check.Findings = append(check.Findings, ScrubFinding{
+				Reason:                ScrubReason_ConflictingDeposit,
+				WithdrawalCredentials: deposit.WithdrawalCredentials,
+				TxHash:                deposit.TxHash,
+				BlockNumber:           deposit.BlockNumber,
+			})
+		}
+		break
+	}
+	if len(check.Findings) > 0 {
+		check.Checked = true
+	}
+	return check
+}
@@ -0,0 +1,144 @@
+package trustednode
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	blst "github.com/supranational/blst/bindings/go"
+
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils"
+	"github.com/rocket-pool/rocketpool-go/utils/validator"
+)
+
+func TestCheckScrubCandidates(t *testing.T) {
+	forkVersion := validator.MainnetGenesisForkVersion
+	minipoolAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	expectedCredentials := validator.GetMinipoolWithdrawalCredentials(minipoolAddress)
+	otherCredentials := validator.GetMinipoolWithdrawalCredentials(common.HexToAddress("0x3333333333333333333333333333333333333333"))
+
+	// Deposits are signed with the key used by the consensus spec BLS tests
+	secretKey := new(blst.SecretKey).Deserialize(common.FromHex("0x263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3"))
+	pubkey := types.BytesToValidatorPubkey(new(blst.P1Affine).From(secretKey).Compress())
+	deposit := func(credentials common.Hash, blockNumber uint64, validSignature bool) utils.DepositData {
+		signingRoot, err := validator.GetDepositSigningRoot(pubkey, credentials, 1000000000, forkVersion)
+		if err != nil {
+			t.Fatalf("error getting signing root: %v", err)
+		}
+		if !validSignature {
+			signingRoot[0] ^= 0xff
+		}
+		signature := new(blst.P2Affine).Sign(secretKey, signingRoot.Bytes(), []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"))
+		return utils.DepositData{
+			Pubkey:                pubkey,
+			WithdrawalCredentials: credentials,
+			Amount:                1000000000,
+			Signature:             types.BytesToValidatorSignature(signature.Compress()),
+			TxHash:                common.BigToHash(new(big.Int).SetUint64(blockNumber)),
+			BlockNumber:           blockNumber,
+		}
+	}
+	conflicting := deposit(otherCredentials, 11, true)
+
+	tests := []struct {
+		name             string
+		vacant           bool
+		prestake         common.Hash
+		deposits         []utils.DepositData
+		expectedChecked  bool
+		expectedFindings []ScrubFinding
+	}{
+		{
+			name:             "vacant",
+			vacant:           true,
+			deposits:         []utils.DepositData{conflicting},
+			expectedFindings: []ScrubFinding{},
+		},
+		{
+			name:             "no deposits yet",
+			prestake:         expectedCredentials,
+			expectedFindings: []ScrubFinding{},
+		},
+		{
+			name:             "bad prestake without deposits",
+			prestake:         otherCredentials,
+			expectedChecked:  true,
+			expectedFindings: []ScrubFinding{{Reason: ScrubReason_PrestakeCredentials, WithdrawalCredentials: otherCredentials}},
+		},
+		{
+			name:             "first deposit matches",
+			prestake:         expectedCredentials,
+			deposits:         []utils.DepositData{deposit(expectedCredentials, 10, true), conflicting},
+			expectedChecked:  true,
+			expectedFindings: []ScrubFinding{},
+		},
+		{
+			name:             "invalid deposits are ignored",
+			prestake:         expectedCredentials,
+			deposits:         []utils.DepositData{deposit(otherCredentials, 9, false), deposit(expectedCredentials, 10, true)},
+			expectedChecked:  true,
+			expectedFindings: []ScrubFinding{},
+		},
+		{
+			name:             "only invalid deposits",
+			prestake:         expectedCredentials,
+			deposits:         []utils.DepositData{deposit(otherCredentials, 9, false)},
+			expectedFindings: []ScrubFinding{},
+		},
+		{
+			name:            "first deposit conflicts",
+			prestake:        expectedCredentials,
+			deposits:        []utils.DepositData{conflicting, deposit(expectedCredentials, 12, true)},
+			expectedChecked: true,
+			expectedFindings: []ScrubFinding{{
+				Reason:                ScrubReason_ConflictingDeposit,
+				WithdrawalCredentials: otherCredentials,
+				TxHash:                conflicting.TxHash,
+				BlockNumber:           11,
+			}},
+		},
+		{
+			name:            "bad prestake and conflicting deposit",
+			prestake:        otherCredentials,
+			deposits:        []utils.DepositData{conflicting},
+			expectedChecked: true,
+			expectedFindings: []ScrubFinding{
+				{Reason: ScrubReason_PrestakeCredentials, WithdrawalCredentials: otherCredentials},
+				{Reason: ScrubReason_ConflictingDeposit, WithdrawalCredentials: otherCredentials, TxHash: conflicting.TxHash, BlockNumber: 11},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidate := ScrubCandidate{
+				MinipoolAddress:               minipoolAddress,
+				Pubkey:                        pubkey,
+				ExpectedWithdrawalCredentials: expectedCredentials,
+				Vacant:                        test.vacant,
+			}
+			if !test.vacant {
+				candidate.Prestake = minipool.PrestakeData{Pubkey: pubkey, WithdrawalCredentials: test.prestake}
+			}
+			deposits := map[types.ValidatorPubkey][]utils.DepositData{pubkey: test.deposits}
+
+			checks := CheckScrubCandidates([]ScrubCandidate{candidate}, deposits, forkVersion)
+			if len(checks) != 1 {
+				t.Fatalf("expected 1 check but got %d", len(checks))
+			}
+			check := checks[0]
+			if check.Checked != test.expectedChecked {
+				t.Errorf("expected checked %t but got %t", test.expectedChecked, check.Checked)
+			}
+			if !reflect.DeepEqual(check.Findings, test.expectedFindings) {
+				t.Errorf("expected findings %+v but got %+v", test.expectedFindings, check.Findings)
+			}
+			if check.ShouldScrub() != (len(test.expectedFindings) > 0) {
+				t.Errorf("unexpected scrub decision %t", check.ShouldScrub())
+			}
+		})
+	}
+}
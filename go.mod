@@ -7,6 +7,7 @@ require (
 	github.com/hashicorp/go-version v1.6.0
 	github.com/princjef/gomarkdoc v0.4.1
 	github.com/prysmaticlabs/go-ssz v0.0.0-20210121151755-f6208871c388
+	github.com/supranational/blst v0.3.14
 	golang.org/x/sync v0.1.0
 )
 
@@ -0,0 +1,163 @@
+package minipool
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// The minimum balance that v3 minipools treat as a full exit instead of skimmed rewards
+var distributionExitThreshold = eth.EthToWei(8)
+
+// The base that node fees and penalty rates are expressed in
+var distributionCalcBase = eth.EthToWei(1)
+
+// The minipool values the delegate's distribution math depends on
+type DistributionParameters struct {
+	Version            uint8    `json:"version"`
+	NodeFee            *big.Int `json:"nodeFee"`
+	NodeDepositBalance *big.Int `json:"nodeDepositBalance"`
+	UserDepositBalance *big.Int `json:"userDepositBalance"`
+	NodeRefundBalance  *big.Int `json:"nodeRefundBalance"`
+	PenaltyRate        *big.Int `json:"penaltyRate"`
+}
+
+// The result of distributing a minipool's balance
+type Distribution struct {
+	Balance          *big.Int `json:"balance"`          // The distributed balance, excluding the node refund
+	FullExit         bool     `json:"fullExit"`         // True if the balance was treated as a full exit, false if it was treated as skimmed rewards
+	NodeShare        *big.Int `json:"nodeShare"`        // Added to the node refund balance
+	UserShare        *big.Int `json:"userShare"`        // Sent to the rETH contract
+	NodeSlashBalance *big.Int `json:"nodeSlashBalance"` // The shortfall the node's RPL will be slashed for, if the balance didn't cover the user deposit
+}
+
+// Get the distribution parameters of a minipool
+func GetDistributionParameters(rp *rocketpool.RocketPool, mp Minipool, opts *bind.CallOpts) (DistributionParameters, error) {
+	nodeDetails, err := mp.GetNodeDetails(opts)
+	if err != nil {
+		return DistributionParameters{}, err
+	}
+	nodeFee, err := mp.GetNodeFeeRaw(opts)
+	if err != nil {
+		return DistributionParameters{}, err
+	}
+	userDepositBalance, err := mp.GetUserDepositBalance(opts)
+	if err != nil {
+		return DistributionParameters{}, err
+	}
+	penaltyRate, err := GetMinipoolPenaltyRate(rp, mp.GetAddress(), opts)
+	if err != nil {
+		return DistributionParameters{}, err
+	}
+	return DistributionParameters{
+		Version:            mp.GetVersion(),
+		NodeFee:            nodeFee,
+		NodeDepositBalance: nodeDetails.DepositBalance,
+		UserDepositBalance: userDepositBalance,
+		NodeRefundBalance:  nodeDetails.RefundBalance,
+		PenaltyRate:        penaltyRate,
+	}, nil
+}
+
+// Calculate the node's share of a balance; this matches the delegate's calculateNodeShare
+func (p DistributionParameters) CalculateNodeShare(balance *big.Int) *big.Int {
+	if p.Version >= 3 && balance.Cmp(distributionExitThreshold) < 0 {
+		// Sub-8 ETH balances are treated as rewards
+		return p.calculateNodeRewards(balance)
+	}
+	return p.calculateExitNodeShare(balance)
+}
+
+// Calculate the user's share of a balance; this matches the delegate's calculateUserShare
+func (p DistributionParameters) CalculateUserShare(balance *big.Int) *big.Int {
+	return big.NewInt(0).Sub(balance, p.CalculateNodeShare(balance))
+}
+
+// Calculate what distributeBalance would do with the given contract balance.
+// Slashing only applies on the first full distribution; the node refund balance is excluded from the distribution as it is on-chain.
+func (p DistributionParameters) CalculateDistribution(contractBalance *big.Int) Distribution {
+	balance := big.NewInt(0).Sub(contractBalance, p.NodeRefundBalance)
+	if balance.Sign() < 0 {
+		balance.SetUint64(0)
+	}
+	distribution := Distribution{
+		Balance:          balance,
+		NodeSlashBalance: big.NewInt(0),
+	}
+
+	if p.Version >= 3 && balance.Cmp(distributionExitThreshold) < 0 {
+		// Skimmed rewards
+		distribution.NodeShare = p.calculateNodeRewards(balance)
+	} else {
+		// Full exit
+		distribution.FullExit = true
+		if balance.Cmp(p.UserDepositBalance) < 0 {
+			distribution.NodeShare = big.NewInt(0)
+			distribution.NodeSlashBalance.Sub(p.UserDepositBalance, balance)
+		} else {
+			distribution.NodeShare = p.calculateExitNodeShare(balance)
+		}
+	}
+	distribution.UserShare = big.NewInt(0).Sub(balance, distribution.NodeShare)
+
+	return distribution
+}
+
+// Compare the offline node and user shares of a balance with the ones calculated by the minipool contract
+func (p DistributionParameters) Verify(mp Minipool, balance *big.Int, opts *bind.CallOpts) error {
+	onchainNodeShare, err := mp.CalculateNodeShare(balance, opts)
+	if err != nil {
+		return err
+	}
+	onchainUserShare, err := mp.CalculateUserShare(balance, opts)
+	if err != nil {
+		return err
+	}
+	if nodeShare := p.CalculateNodeShare(balance); nodeShare.Cmp(onchainNodeShare) != 0 {
+		return fmt.Errorf("node share of %s for minipool %s was %s but the contract calculated %s", balance.String(), mp.GetAddress().Hex(), nodeShare.String(), onchainNodeShare.String())
+	}
+	if userShare := p.CalculateUserShare(balance); userShare.Cmp(onchainUserShare) != 0 {
+		return fmt.Errorf("user share of %s for minipool %s was %s but the contract calculated %s", balance.String(), mp.GetAddress().Hex(), userShare.String(), onchainUserShare.String())
+	}
+	return nil
+}
+
+// Calculate the node's share of a balance that's treated as an exit: its capital back plus its portion of the rewards, less any penalty
+func (p DistributionParameters) calculateExitNodeShare(balance *big.Int) *big.Int {
+	nodeShare := big.NewInt(0)
+	capital := big.NewInt(0).Add(p.UserDepositBalance, p.NodeDepositBalance)
+	if balance.Cmp(capital) > 0 {
+		rewards := big.NewInt(0).Sub(balance, capital)
+		nodeShare.Add(p.NodeDepositBalance, p.calculateNodeRewards(rewards))
+	} else if balance.Cmp(p.UserDepositBalance) > 0 {
+		nodeShare.Sub(balance, p.UserDepositBalance)
+	}
+
+	// Apply the penalty
+	if p.PenaltyRate != nil && p.PenaltyRate.Sign() > 0 {
+		penalty := big.NewInt(0).Mul(nodeShare, p.PenaltyRate)
+		penalty.Div(penalty, distributionCalcBase)
+		if penalty.Cmp(nodeShare) > 0 {
+			penalty.Set(nodeShare)
+		}
+		nodeShare.Sub(nodeShare, penalty)
+	}
+	return nodeShare
+}
+
+// Calculate the node's portion of rewards: its share by capital plus its commission on the user's share
+func (p DistributionParameters) calculateNodeRewards(rewards *big.Int) *big.Int {
+	capital := big.NewInt(0).Add(p.UserDepositBalance, p.NodeDepositBalance)
+	if capital.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	nodePortion := big.NewInt(0).Mul(rewards, p.NodeDepositBalance)
+	nodePortion.Div(nodePortion, capital)
+	userPortion := big.NewInt(0).Sub(rewards, nodePortion)
+	commission := big.NewInt(0).Mul(userPortion, p.NodeFee)
+	commission.Div(commission, distributionCalcBase)
+	return nodePortion.Add(nodePortion, commission)
+}
@@ -0,0 +1,165 @@
+package minipool
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestCalculateDistribution(t *testing.T) {
+	wei := func(amount string) *big.Int {
+		value, _ := big.NewInt(0).SetString(amount, 10)
+		return value
+	}
+	leb8 := DistributionParameters{
+		Version:            3,
+		NodeFee:            wei("140000000000000000"),
+		NodeDepositBalance: eth.EthToWei(8),
+		UserDepositBalance: eth.EthToWei(24),
+		NodeRefundBalance:  big.NewInt(0),
+		PenaltyRate:        big.NewInt(0),
+	}
+	withRefund := leb8
+	withRefund.NodeRefundBalance = eth.EthToWei(1)
+	penalised := leb8
+	penalised.PenaltyRate = wei("500000000000000000")
+	legacy := DistributionParameters{
+		Version:            2,
+		NodeFee:            wei("150000000000000000"),
+		NodeDepositBalance: eth.EthToWei(16),
+		UserDepositBalance: eth.EthToWei(16),
+		NodeRefundBalance:  big.NewInt(0),
+	}
+
+	tests := []struct {
+		name            string
+		parameters      DistributionParameters
+		contractBalance *big.Int
+		expected        Distribution
+	}{
+		{
+			name:            "skimmed rewards",
+			parameters:      leb8,
+			contractBalance: wei("500000000000000000"),
+			expected: Distribution{
+				Balance:          wei("500000000000000000"),
+				NodeShare:        wei("177500000000000000"), // 0.125 by capital plus 14% of the user's 0.375
+				UserShare:        wei("322500000000000000"),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+		{
+			name:            "skimmed rewards with a node refund",
+			parameters:      withRefund,
+			contractBalance: wei("1500000000000000000"),
+			expected: Distribution{
+				Balance:          wei("500000000000000000"),
+				NodeShare:        wei("177500000000000000"),
+				UserShare:        wei("322500000000000000"),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+		{
+			name:            "exit with rewards",
+			parameters:      leb8,
+			contractBalance: wei("32500000000000000000"),
+			expected: Distribution{
+				Balance:          wei("32500000000000000000"),
+				FullExit:         true,
+				NodeShare:        wei("8177500000000000000"),
+				UserShare:        wei("24322500000000000000"),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+		{
+			name:            "exit with a loss covered by the bond",
+			parameters:      leb8,
+			contractBalance: eth.EthToWei(30),
+			expected: Distribution{
+				Balance:          eth.EthToWei(30),
+				FullExit:         true,
+				NodeShare:        eth.EthToWei(6),
+				UserShare:        eth.EthToWei(24),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+		{
+			name:            "exit with a loss bigger than the bond",
+			parameters:      leb8,
+			contractBalance: eth.EthToWei(20),
+			expected: Distribution{
+				Balance:          eth.EthToWei(20),
+				FullExit:         true,
+				NodeShare:        big.NewInt(0),
+				UserShare:        eth.EthToWei(20),
+				NodeSlashBalance: eth.EthToWei(4),
+			},
+		},
+		{
+			name:            "exit with a penalty",
+			parameters:      penalised,
+			contractBalance: wei("32500000000000000000"),
+			expected: Distribution{
+				Balance:          wei("32500000000000000000"),
+				FullExit:         true,
+				NodeShare:        wei("4088750000000000000"),
+				UserShare:        wei("28411250000000000000"),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+		{
+			name:            "legacy minipools treat every balance as an exit",
+			parameters:      legacy,
+			contractBalance: eth.EthToWei(33),
+			expected: Distribution{
+				Balance:          eth.EthToWei(33),
+				FullExit:         true,
+				NodeShare:        wei("16575000000000000000"), // 16 back, 0.5 by capital and 15% of the user's 0.5
+				UserShare:        wei("16425000000000000000"),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+		{
+			name:            "empty",
+			parameters:      leb8,
+			contractBalance: big.NewInt(0),
+			expected: Distribution{
+				Balance:          big.NewInt(0),
+				NodeShare:        big.NewInt(0),
+				UserShare:        big.NewInt(0),
+				NodeSlashBalance: big.NewInt(0),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distribution := test.parameters.CalculateDistribution(test.contractBalance)
+			if distribution.FullExit != test.expected.FullExit {
+				t.Errorf("expected full exit to be %t", test.expected.FullExit)
+			}
+			for _, value := range []struct {
+				name     string
+				actual   *big.Int
+				expected *big.Int
+			}{
+				{"balance", distribution.Balance, test.expected.Balance},
+				{"node share", distribution.NodeShare, test.expected.NodeShare},
+				{"user share", distribution.UserShare, test.expected.UserShare},
+				{"node slash balance", distribution.NodeSlashBalance, test.expected.NodeSlashBalance},
+			} {
+				if value.actual.Cmp(value.expected) != 0 {
+					t.Errorf("expected %s %s, got %s", value.name, value.expected, value.actual)
+				}
+			}
+
+			if nodeShare := test.parameters.CalculateNodeShare(distribution.Balance); nodeShare.Cmp(test.expected.NodeShare) != 0 {
+				t.Errorf("CalculateNodeShare returned %s", nodeShare)
+			}
+			if userShare := test.parameters.CalculateUserShare(distribution.Balance); userShare.Cmp(test.expected.UserShare) != 0 {
+				t.Errorf("CalculateUserShare returned %s", userShare)
+			}
+		})
+	}
+}
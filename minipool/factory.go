@@ -9,6 +9,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 
 	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils"
 )
 
 // Get the address of a minipool based on the node address and a salt
@@ -32,3 +33,12 @@ func getRocketMinipoolFactory(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*
 	defer rocketMinipoolFactoryLock.Unlock()
 	return rp.GetContract("rocketMinipoolFactory", opts)
 }
+
+// Get the factory address and minipool init code hash needed to predict minipool addresses offline
+func GetAddressPredictionParameters(rp *rocketpool.RocketPool, opts *bind.CallOpts) (common.Address, common.Hash, error) {
+	addresses, err := rp.GetAddresses(opts, "rocketMinipoolFactory", "rocketMinipoolBase")
+	if err != nil {
+		return common.Address{}, common.Hash{}, fmt.Errorf("error getting minipool factory and base addresses: %w", err)
+	}
+	return *addresses[0], utils.GetMinimalProxyInitCodeHash(*addresses[1]), nil
+}
@@ -0,0 +1,227 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// The next step in a bond reduction
+type BondReductionStep string
+
+const (
+	BondReductionStep_Begin  BondReductionStep = "begin"
+	BondReductionStep_Wait   BondReductionStep = "wait"
+	BondReductionStep_Reduce BondReductionStep = "reduce"
+	BondReductionStep_None   BondReductionStep = "none"
+)
+
+// What a minipool and its node will look like after a bond reduction
+type BondReductionOutcome struct {
+	NewBond         *big.Int `json:"new_bond"`
+	NewFee          *big.Int `json:"new_fee"`           // The network node fee at the time of the reduction
+	Credit          *big.Int `json:"credit"`            // The ETH added to the node's deposit credit
+	EthMatchedAfter *big.Int `json:"eth_matched_after"` // The node's borrowed ETH after the reduction
+}
+
+// The bond reduction status of a minipool, and the means to move it along.
+// It reflects the chain at the block it was loaded from, so reload it with NewBondReduction after each transaction is mined.
+type BondReduction struct {
+	Minipool         state.NativeMinipoolDetails `json:"minipool"`
+	Settings         *state.NetworkDetails       `json:"-"`
+	BlockTime        time.Time                   `json:"block_time"`
+	Window           Window                      `json:"window"` // The window as of BlockTime
+	NetworkNodeFee   *big.Int                    `json:"network_node_fee"`
+	ValidBondAmounts []*big.Int                  `json:"valid_bond_amounts"`
+	EthMatched       *big.Int                    `json:"eth_matched"`
+	EthMatchedLimit  *big.Int                    `json:"eth_matched_limit"`
+
+	rp *rocketpool.RocketPool
+}
+
+// Load the bond reduction status of a minipool
+func NewBondReduction(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, minipoolAddress common.Address) (*BondReduction, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+
+	mpd, err := state.GetNativeMinipoolDetails(rp, contracts, minipoolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool details: %w", err)
+	}
+	settings, err := state.NewNetworkDetails(rp, contracts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network details: %w", err)
+	}
+	header, err := rp.Client.HeaderByNumber(context.Background(), contracts.ElBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %s: %w", contracts.ElBlockNumber.String(), err)
+	}
+
+	reduction := &BondReduction{
+		Minipool:  mpd,
+		Settings:  settings,
+		BlockTime: time.Unix(int64(header.Time), 0),
+		rp:        rp,
+	}
+	reduction.Window = GetBondReductionWindow(&reduction.Minipool, settings, reduction.BlockTime)
+
+	// Node and network values the reduction depends on
+	contracts.Multicaller.AddCall(contracts.RocketNetworkFees, &reduction.NetworkNodeFee, "getNodeFee")
+	contracts.Multicaller.AddCall(contracts.RocketNodeDeposit, &reduction.ValidBondAmounts, "getDepositAmounts")
+	contracts.Multicaller.AddCall(contracts.RocketNodeStaking, &reduction.EthMatched, "getNodeETHMatched", mpd.NodeAddress)
+	contracts.Multicaller.AddCall(contracts.RocketNodeStaking, &reduction.EthMatchedLimit, "getNodeETHMatchedLimit", mpd.NodeAddress)
+	_, err = contracts.Multicaller.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+
+	return reduction, nil
+}
+
+// Get the bond reduction window as of the given time
+func (r *BondReduction) GetWindow(now time.Time) Window {
+	return GetBondReductionWindow(&r.Minipool, r.Settings, now)
+}
+
+// Get the next step of the bond reduction as of the given time, and the earliest time it can be taken
+func (r *BondReduction) GetNextStep(now time.Time) (BondReductionStep, time.Time) {
+	window := r.GetWindow(now)
+	switch window.Phase {
+	case WindowPhase_NotStarted, WindowPhase_Expired:
+		return BondReductionStep_Begin, now
+	case WindowPhase_Waiting:
+		return BondReductionStep_Wait, window.Start
+	case WindowPhase_Open:
+		return BondReductionStep_Reduce, now
+	}
+	return BondReductionStep_None, time.Time{}
+}
+
+// Check that the minipool can begin reducing its bond to the given amount
+func (r *BondReduction) ValidateTarget(newBond *big.Int, now time.Time) error {
+	mpd := &r.Minipool
+	if mpd.Version < 3 {
+		return fmt.Errorf("bond reduction is not supported by v%d minipools", mpd.Version)
+	}
+	if !r.Settings.BondReductionEnabled {
+		return fmt.Errorf("bond reductions are currently disabled")
+	}
+	if mpd.Status != types.Staking {
+		return fmt.Errorf("minipool %s is not staking", mpd.MinipoolAddress.Hex())
+	}
+	switch r.GetWindow(now).Phase {
+	case WindowPhase_Cancelled:
+		return fmt.Errorf("the Oracle DAO cancelled the bond reduction for minipool %s", mpd.MinipoolAddress.Hex())
+	case WindowPhase_Waiting, WindowPhase_Open:
+		return fmt.Errorf("minipool %s already has a bond reduction in progress", mpd.MinipoolAddress.Hex())
+	}
+	if newBond.Cmp(mpd.NodeDepositBalance) >= 0 {
+		return fmt.Errorf("the new bond of %s must be lower than the current bond of %s", newBond.String(), mpd.NodeDepositBalance.String())
+	}
+	if !r.isValidBondAmount(newBond) {
+		return fmt.Errorf("%s is not a valid bond amount", newBond.String())
+	}
+	outcome := r.CalculateOutcome(newBond)
+	if outcome.EthMatchedAfter.Cmp(r.EthMatchedLimit) > 0 {
+		return fmt.Errorf("the node would have %s borrowed ETH after the reduction, but its RPL stake only supports %s", outcome.EthMatchedAfter.String(), r.EthMatchedLimit.String())
+	}
+	return nil
+}
+
+// Calculate what the minipool and its node will look like after reducing the bond to the given amount
+func (r *BondReduction) CalculateOutcome(newBond *big.Int) BondReductionOutcome {
+	credit := big.NewInt(0).Sub(r.Minipool.NodeDepositBalance, newBond)
+	if credit.Sign() < 0 {
+		credit.SetUint64(0)
+	}
+	return BondReductionOutcome{
+		NewBond:         newBond,
+		NewFee:          r.NetworkNodeFee,
+		Credit:          credit,
+		EthMatchedAfter: big.NewInt(0).Add(r.EthMatched, credit),
+	}
+}
+
+// Estimate the gas of Begin
+func (r *BondReduction) EstimateBeginGas(newBond *big.Int, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := r.ValidateTarget(newBond, r.BlockTime); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return minipool.EstimateBeginReduceBondAmountGas(r.rp, r.Minipool.MinipoolAddress, newBond, opts)
+}
+
+// Begin reducing the minipool's bond to the given amount, if the chain allowed it as of BlockTime
+func (r *BondReduction) Begin(newBond *big.Int, opts *bind.TransactOpts) (common.Hash, error) {
+	if err := r.ValidateTarget(newBond, r.BlockTime); err != nil {
+		return common.Hash{}, err
+	}
+	return minipool.BeginReduceBondAmount(r.rp, r.Minipool.MinipoolAddress, newBond, opts)
+}
+
+// Estimate the gas of Reduce
+func (r *BondReduction) EstimateReduceGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	mp, err := r.getOpenMinipool(r.BlockTime)
+	if err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return mp.EstimateReduceBondAmountGas(opts)
+}
+
+// Complete the bond reduction once its window is open as of BlockTime
+func (r *BondReduction) Reduce(opts *bind.TransactOpts) (common.Hash, error) {
+	mp, err := r.getOpenMinipool(r.BlockTime)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return mp.ReduceBondAmount(opts)
+}
+
+// Take the next step of the bond reduction: begin it if it hasn't been started (or the last window expired), or complete it if the window is open.
+// The new bond is only used when beginning. Returns BondReductionStep_Wait with no transaction if the window isn't open as of BlockTime;
+// reload the bond reduction with NewBondReduction to pick up a later block.
+func (r *BondReduction) Advance(newBond *big.Int, opts *bind.TransactOpts) (BondReductionStep, common.Hash, error) {
+	step, _ := r.GetNextStep(r.BlockTime)
+	switch step {
+	case BondReductionStep_Begin:
+		hash, err := r.Begin(newBond, opts)
+		return step, hash, err
+	case BondReductionStep_Reduce:
+		hash, err := r.Reduce(opts)
+		return step, hash, err
+	case BondReductionStep_Wait:
+		return step, common.Hash{}, nil
+	}
+	return step, common.Hash{}, fmt.Errorf("the bond reduction for minipool %s cannot continue", r.Minipool.MinipoolAddress.Hex())
+}
+
+// Get the minipool binding if its bond reduction window is open
+func (r *BondReduction) getOpenMinipool(now time.Time) (*minipool.MinipoolAdapter, error) {
+	window := r.GetWindow(now)
+	if window.Phase != WindowPhase_Open {
+		return nil, fmt.Errorf("the bond reduction window for minipool %s is not open (%s)", r.Minipool.MinipoolAddress.Hex(), window.Phase.String())
+	}
+	mp, err := minipool.NewMinipoolFromVersion(r.rp, r.Minipool.MinipoolAddress, r.Minipool.Version, nil)
+	if err != nil {
+		return nil, err
+	}
+	return minipool.NewMinipoolAdapter(mp), nil
+}
+
+// Check if the bond is one of the network's allowed deposit amounts
+func (r *BondReduction) isValidBondAmount(bond *big.Int) bool {
+	for _, amount := range r.ValidBondAmounts {
+		if amount.Cmp(bond) == 0 {
+			return true
+		}
+	}
+	return false
+}
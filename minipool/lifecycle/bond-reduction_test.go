@@ -0,0 +1,162 @@
+package lifecycle
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+func TestBondReductionValidateTarget(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// Every test starts from a staking 16 ETH minipool reducing to 8 ETH, with room for the extra 8 borrowed ETH
+	tests := []struct {
+		name    string
+		newBond float64
+		modify  func(r *BondReduction)
+		err     string
+	}{
+		{name: "valid", newBond: 8},
+		{
+			name:    "legacy minipool",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Minipool.Version = 2 },
+			err:     "not supported by v2 minipools",
+		},
+		{
+			name:    "reductions disabled",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Settings.BondReductionEnabled = false },
+			err:     "disabled",
+		},
+		{
+			name:    "not staking",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Minipool.Status = types.Prelaunch },
+			err:     "is not staking",
+		},
+		{
+			name:    "cancelled",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Minipool.ReduceBondCancelled = true },
+			err:     "cancelled",
+		},
+		{
+			name:    "already waiting",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Minipool.ReduceBondTime = big.NewInt(now.Add(-time.Hour).Unix()) },
+			err:     "already has a bond reduction in progress",
+		},
+		{
+			name:    "already open",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Minipool.ReduceBondTime = big.NewInt(now.Add(-13 * time.Hour).Unix()) },
+			err:     "already has a bond reduction in progress",
+		},
+		{
+			name:    "previous window expired",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.Minipool.ReduceBondTime = big.NewInt(now.Add(-15 * time.Hour).Unix()) },
+		},
+		{
+			name:    "not lower than the current bond",
+			newBond: 16,
+			err:     "must be lower than the current bond",
+		},
+		{
+			name:    "not a valid bond amount",
+			newBond: 4,
+			err:     "is not a valid bond amount",
+		},
+		{
+			name:    "over the ETH matched limit",
+			newBond: 8,
+			modify:  func(r *BondReduction) { r.EthMatchedLimit = eth.EthToWei(20) },
+			err:     "its RPL stake only supports",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reduction := &BondReduction{
+				Minipool: state.NativeMinipoolDetails{
+					MinipoolAddress:    common.HexToAddress("0x1111111111111111111111111111111111111111"),
+					Version:            3,
+					Status:             types.Staking,
+					NodeDepositBalance: eth.EthToWei(16),
+					ReduceBondTime:     big.NewInt(0),
+				},
+				Settings: &state.NetworkDetails{
+					BondReductionEnabled:      true,
+					BondReductionWindowStart:  12 * time.Hour,
+					BondReductionWindowLength: 2 * time.Hour,
+				},
+				BlockTime:        now,
+				NetworkNodeFee:   eth.EthToWei(0.14),
+				ValidBondAmounts: []*big.Int{eth.EthToWei(8), eth.EthToWei(16)},
+				EthMatched:       eth.EthToWei(16),
+				EthMatchedLimit:  eth.EthToWei(24),
+			}
+			if test.modify != nil {
+				test.modify(reduction)
+			}
+
+			err := reduction.ValidateTarget(eth.EthToWei(test.newBond), now)
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected an error containing '%s', got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestBondReductionCalculateOutcome(t *testing.T) {
+	tests := []struct {
+		name                    string
+		currentBond             float64
+		newBond                 float64
+		expectedCredit          float64
+		expectedEthMatchedAfter float64
+	}{
+		{name: "16 to 8", currentBond: 16, newBond: 8, expectedCredit: 8, expectedEthMatchedAfter: 24},
+		{name: "16 to 4", currentBond: 16, newBond: 4, expectedCredit: 12, expectedEthMatchedAfter: 28},
+		{name: "no reduction", currentBond: 8, newBond: 8, expectedCredit: 0, expectedEthMatchedAfter: 16},
+		{name: "increase", currentBond: 8, newBond: 16, expectedCredit: 0, expectedEthMatchedAfter: 16},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reduction := &BondReduction{
+				Minipool: state.NativeMinipoolDetails{
+					NodeDepositBalance: eth.EthToWei(test.currentBond),
+				},
+				NetworkNodeFee: eth.EthToWei(0.14),
+				EthMatched:     eth.EthToWei(16),
+			}
+			outcome := reduction.CalculateOutcome(eth.EthToWei(test.newBond))
+			if eth.WeiToEth(outcome.NewBond) != test.newBond {
+				t.Errorf("expected a new bond of %f but got %f", test.newBond, eth.WeiToEth(outcome.NewBond))
+			}
+			if eth.WeiToEth(outcome.NewFee) != 0.14 {
+				t.Errorf("expected the network fee of 0.14 but got %f", eth.WeiToEth(outcome.NewFee))
+			}
+			if eth.WeiToEth(outcome.Credit) != test.expectedCredit {
+				t.Errorf("expected a credit of %f but got %f", test.expectedCredit, eth.WeiToEth(outcome.Credit))
+			}
+			if eth.WeiToEth(outcome.EthMatchedAfter) != test.expectedEthMatchedAfter {
+				t.Errorf("expected %f ETH matched after the reduction but got %f", test.expectedEthMatchedAfter, eth.WeiToEth(outcome.EthMatchedAfter))
+			}
+		})
+	}
+}
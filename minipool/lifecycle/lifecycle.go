@@ -0,0 +1,385 @@
+package lifecycle
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// Minipool actions
+type Action string
+
+const (
+	Action_Stake               Action = "stake"
+	Action_Promote             Action = "promote"
+	Action_Dissolve            Action = "dissolve"
+	Action_Close               Action = "close"
+	Action_DistributeRewards   Action = "distributeRewards"
+	Action_DistributeBalance   Action = "distributeBalance"
+	Action_BeginUserDistribute Action = "beginUserDistribute"
+	Action_UserDistribute      Action = "userDistribute"
+	Action_Finalise            Action = "finalise"
+	Action_BeginBondReduction  Action = "beginBondReduction"
+	Action_ReduceBond          Action = "reduceBond"
+)
+
+// All of the actions, in the order they're evaluated
+var Actions = []Action{
+	Action_Stake,
+	Action_Promote,
+	Action_Dissolve,
+	Action_Close,
+	Action_DistributeRewards,
+	Action_DistributeBalance,
+	Action_BeginUserDistribute,
+	Action_UserDistribute,
+	Action_Finalise,
+	Action_BeginBondReduction,
+	Action_ReduceBond,
+}
+
+// Phases of a timed window, such as the bond reduction and user distribute windows
+type WindowPhase uint8
+
+const (
+	WindowPhase_NotStarted WindowPhase = iota
+	WindowPhase_Waiting
+	WindowPhase_Open
+	WindowPhase_Expired
+	WindowPhase_Cancelled
+)
+
+var WindowPhases = []string{"NotStarted", "Waiting", "Open", "Expired", "Cancelled"}
+
+// String conversion
+func (p WindowPhase) String() string {
+	if int(p) >= len(WindowPhases) {
+		return ""
+	}
+	return WindowPhases[p]
+}
+
+// A timed window and where the minipool is in it
+type Window struct {
+	Phase WindowPhase `json:"phase"`
+	Start time.Time   `json:"start"`
+	End   time.Time   `json:"end"`
+}
+
+// The minimum balance for a distribution to be treated as a full exit instead of skimmed rewards
+var ExitBalanceThreshold = eth.EthToWei(8)
+
+// The smallest bond a minipool's bond can be reduced to
+var MinimumBond = eth.EthToWei(8)
+
+// The current lifecycle state of a minipool
+type MinipoolState struct {
+	Status               types.MinipoolStatus `json:"status"`
+	Version              uint8                `json:"version"`
+	Finalised            bool                 `json:"finalised"`
+	Vacant               bool                 `json:"vacant"`
+	DistributableBalance *big.Int             `json:"distributable_balance"`
+	BondReduction        Window               `json:"bond_reduction"`
+	UserDistribute       Window               `json:"user_distribute"`
+}
+
+// Whether an action can be performed, and if not, why and when it can be
+type ActionPlan struct {
+	Action       Action    `json:"action"`
+	Allowed      bool      `json:"allowed"`
+	EarliestTime time.Time `json:"earliest_time"` // Zero if the action is allowed now or waiting won't make it allowed
+	Reason       string    `json:"reason"`
+}
+
+// The lifecycle state of a minipool and the plan for each action
+type Plan struct {
+	State   MinipoolState `json:"state"`
+	Actions []ActionPlan  `json:"actions"`
+}
+
+// Get the phase of a minipool's bond reduction window
+func GetBondReductionWindow(mpd *state.NativeMinipoolDetails, settings *state.NetworkDetails, now time.Time) Window {
+	if mpd.ReduceBondCancelled {
+		return Window{Phase: WindowPhase_Cancelled}
+	}
+	if mpd.ReduceBondTime == nil || mpd.ReduceBondTime.Sign() == 0 {
+		return Window{Phase: WindowPhase_NotStarted}
+	}
+	start := time.Unix(mpd.ReduceBondTime.Int64(), 0).Add(settings.BondReductionWindowStart)
+	return newWindow(start, start.Add(settings.BondReductionWindowLength), now)
+}
+
+// Get the phase of a minipool's user distribute window, given the time beginUserDistribute was called (zero if it hasn't been)
+func GetUserDistributeWindow(userDistributeTime time.Time, settings *state.NetworkDetails, now time.Time) Window {
+	if userDistributeTime.IsZero() {
+		return Window{Phase: WindowPhase_NotStarted}
+	}
+	start := userDistributeTime.Add(settings.UserDistributeWindowStart)
+	return newWindow(start, start.Add(settings.UserDistributeWindowLength), now)
+}
+
+// Create a window and work out which phase it's in
+func newWindow(start time.Time, end time.Time, now time.Time) Window {
+	window := Window{
+		Start: start,
+		End:   end,
+	}
+	switch {
+	case now.Before(start):
+		window.Phase = WindowPhase_Waiting
+	case now.After(end):
+		window.Phase = WindowPhase_Expired
+	default:
+		window.Phase = WindowPhase_Open
+	}
+	return window
+}
+
+// Plan the actions a minipool's node operator can take at the given time.
+// The user distribute time isn't exposed by the minipool contract, so callers that track it can provide it; use the zero time otherwise.
+func NewPlan(mpd *state.NativeMinipoolDetails, settings *state.NetworkDetails, userDistributeTime time.Time, now time.Time) *Plan {
+	distributableBalance := big.NewInt(0).Sub(mpd.Balance, mpd.NodeRefundBalance)
+	if distributableBalance.Sign() < 0 {
+		distributableBalance.SetUint64(0)
+	}
+	plan := &Plan{
+		State: MinipoolState{
+			Status:               mpd.Status,
+			Version:              mpd.Version,
+			Finalised:            mpd.Finalised,
+			Vacant:               mpd.IsVacant,
+			DistributableBalance: distributableBalance,
+			BondReduction:        GetBondReductionWindow(mpd, settings, now),
+			UserDistribute:       GetUserDistributeWindow(userDistributeTime, settings, now),
+		},
+	}
+
+	statusTime := time.Unix(mpd.StatusTime.Int64(), 0)
+	launchTimeout := time.Duration(settings.MinipoolLaunchTimeout.Int64()) * time.Second
+	for _, action := range Actions {
+		var actionPlan ActionPlan
+		if mpd.Finalised {
+			actionPlan = blocked("the minipool has been finalised")
+		} else {
+			actionPlan = plan.evaluate(action, mpd, settings, statusTime, launchTimeout, now)
+		}
+		actionPlan.Action = action
+		plan.Actions = append(plan.Actions, actionPlan)
+	}
+
+	return plan
+}
+
+// Get the plan for a single action
+func (p *Plan) GetAction(action Action) ActionPlan {
+	for _, actionPlan := range p.Actions {
+		if actionPlan.Action == action {
+			return actionPlan
+		}
+	}
+	return ActionPlan{Action: action, Reason: "unknown action"}
+}
+
+// Get the actions that are allowed right now
+func (p *Plan) GetAllowedActions() []Action {
+	actions := []Action{}
+	for _, actionPlan := range p.Actions {
+		if actionPlan.Allowed {
+			actions = append(actions, actionPlan.Action)
+		}
+	}
+	return actions
+}
+
+// Evaluate a single action against the minipool's state
+func (p *Plan) evaluate(action Action, mpd *state.NativeMinipoolDetails, settings *state.NetworkDetails, statusTime time.Time, launchTimeout time.Duration, now time.Time) ActionPlan {
+	s := &p.State
+	isExitBalance := s.DistributableBalance.Cmp(ExitBalanceThreshold) >= 0
+
+	switch action {
+	case Action_Stake:
+		if s.Status == types.Initialized {
+			return blocked("the minipool is waiting in the deposit queue to be assigned user ETH")
+		}
+		if s.Status != types.Prelaunch {
+			return blocked("the minipool is not in prelaunch")
+		}
+		if s.Vacant {
+			return blocked("vacant minipools are promoted instead of staked")
+		}
+		return waitUntil(statusTime.Add(settings.ScrubPeriod), now, "the scrub period has not ended yet")
+
+	case Action_Promote:
+		if s.Version < 3 {
+			return blocked(fmt.Sprintf("promotion is not supported by v%d minipools", s.Version))
+		}
+		if !s.Vacant {
+			return blocked("only vacant minipools can be promoted")
+		}
+		if s.Status != types.Prelaunch {
+			return blocked("the minipool is not in prelaunch")
+		}
+		return waitUntil(statusTime.Add(settings.PromotionScrubPeriod), now, "the promotion scrub period has not ended yet")
+
+	case Action_Dissolve:
+		if s.Status == types.Initialized {
+			// Legacy minipools can be dissolved by their owner while they wait in the queue, but from Atlas they can't leave it
+			if s.Version < 3 {
+				return allowed()
+			}
+			return blocked("the minipool is waiting in the deposit queue and can't be dissolved until it has been assigned")
+		}
+		if s.Status != types.Prelaunch {
+			return blocked("the minipool is not in prelaunch")
+		}
+		return waitUntil(statusTime.Add(launchTimeout), now, "the minipool has not timed out yet")
+
+	case Action_Close:
+		if s.Status != types.Dissolved {
+			return blocked("only dissolved minipools can be closed")
+		}
+		return allowed()
+
+	case Action_DistributeRewards:
+		if s.Version < 3 {
+			return blocked(fmt.Sprintf("rewards-only distribution is not supported by v%d minipools", s.Version))
+		}
+		if s.Status != types.Staking {
+			return blocked("the minipool is not staking")
+		}
+		if isExitBalance {
+			return blocked("the balance is 8 ETH or more, so it must be distributed as a full exit")
+		}
+		if s.DistributableBalance.Sign() == 0 {
+			return blocked("there is no balance to distribute")
+		}
+		return allowed()
+
+	case Action_DistributeBalance:
+		if s.Version < 3 {
+			if s.Status != types.Staking && s.Status != types.Withdrawable {
+				return blocked("the minipool is not staking or withdrawable")
+			}
+			return allowed()
+		}
+		if s.Status != types.Staking {
+			return blocked("the minipool is not staking")
+		}
+		if !isExitBalance {
+			return blocked("the balance is under 8 ETH, so it can only be distributed as rewards")
+		}
+		return allowed()
+
+	case Action_BeginUserDistribute:
+		if s.Version < 3 {
+			return blocked(fmt.Sprintf("user distribution is not supported by v%d minipools", s.Version))
+		}
+		if s.Status != types.Staking {
+			return blocked("the minipool is not staking")
+		}
+		if !isExitBalance {
+			return blocked("the balance is under 8 ETH")
+		}
+		switch s.UserDistribute.Phase {
+		case WindowPhase_Waiting, WindowPhase_Open:
+			return waitUntil(s.UserDistribute.End, now, "a user distribution is already in progress")
+		}
+		return allowed()
+
+	case Action_UserDistribute:
+		if s.Version < 3 {
+			return blocked(fmt.Sprintf("user distribution is not supported by v%d minipools", s.Version))
+		}
+		if s.Status != types.Staking {
+			return blocked("the minipool is not staking")
+		}
+		if !isExitBalance {
+			return blocked("the balance is under 8 ETH")
+		}
+		switch s.UserDistribute.Phase {
+		case WindowPhase_Waiting:
+			return waitUntil(s.UserDistribute.Start, now, "the user distribute window has not opened yet")
+		case WindowPhase_Open:
+			return allowed()
+		case WindowPhase_Expired:
+			return blocked("the user distribute window has expired; it must be started again")
+		}
+		return blocked("the user distribute window has not been started")
+
+	case Action_Finalise:
+		if s.Version < 3 {
+			if s.Status != types.Withdrawable {
+				return blocked("the minipool is not withdrawable")
+			}
+			return allowed()
+		}
+		if !mpd.UserDistributed {
+			return blocked("the minipool can only be finalised manually after a user distribution")
+		}
+		return allowed()
+
+	case Action_BeginBondReduction:
+		if s.Version < 3 {
+			return blocked(fmt.Sprintf("bond reduction is not supported by v%d minipools", s.Version))
+		}
+		if !settings.BondReductionEnabled {
+			return blocked("bond reductions are currently disabled")
+		}
+		if s.Status != types.Staking {
+			return blocked("the minipool is not staking")
+		}
+		if mpd.NodeDepositBalance.Cmp(MinimumBond) <= 0 {
+			return blocked("the bond is already at the minimum")
+		}
+		switch s.BondReduction.Phase {
+		case WindowPhase_Cancelled:
+			return blocked("the Oracle DAO cancelled this minipool's bond reduction")
+		case WindowPhase_Waiting, WindowPhase_Open:
+			return waitUntil(s.BondReduction.End, now, "a bond reduction is already in progress")
+		}
+		return allowed()
+
+	case Action_ReduceBond:
+		if s.Version < 3 {
+			return blocked(fmt.Sprintf("bond reduction is not supported by v%d minipools", s.Version))
+		}
+		if s.Status != types.Staking {
+			return blocked("the minipool is not staking")
+		}
+		switch s.BondReduction.Phase {
+		case WindowPhase_Cancelled:
+			return blocked("the Oracle DAO cancelled this minipool's bond reduction")
+		case WindowPhase_Waiting:
+			return waitUntil(s.BondReduction.Start, now, "the bond reduction window has not opened yet")
+		case WindowPhase_Open:
+			return allowed()
+		case WindowPhase_Expired:
+			return blocked("the bond reduction window has expired; it must be started again")
+		}
+		return blocked("a bond reduction has not been started")
+	}
+
+	return blocked("unknown action")
+}
+
+// An action that's allowed
+func allowed() ActionPlan {
+	return ActionPlan{Allowed: true}
+}
+
+// An action that isn't allowed, and won't become allowed by waiting
+func blocked(reason string) ActionPlan {
+	return ActionPlan{Reason: reason}
+}
+
+// An action that's allowed once the given time has passed
+func waitUntil(earliest time.Time, now time.Time, reason string) ActionPlan {
+	if now.Before(earliest) {
+		return ActionPlan{EarliestTime: earliest, Reason: reason}
+	}
+	return allowed()
+}
@@ -0,0 +1,226 @@
+package lifecycle
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+func TestNewPlan(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	settings := &state.NetworkDetails{
+		ScrubPeriod:                12 * time.Hour,
+		PromotionScrubPeriod:       72 * time.Hour,
+		MinipoolLaunchTimeout:      big.NewInt(int64((72 * time.Hour).Seconds())),
+		BondReductionEnabled:       true,
+		BondReductionWindowStart:   12 * time.Hour,
+		BondReductionWindowLength:  2 * time.Hour,
+		UserDistributeWindowStart:  90 * time.Hour,
+		UserDistributeWindowLength: 2 * time.Hour,
+	}
+
+	tests := []struct {
+		name              string
+		status            types.MinipoolStatus
+		version           uint8
+		statusAge         time.Duration
+		balance           *big.Int
+		bond              *big.Int
+		vacant            bool
+		finalised         bool
+		reduceBondAge     time.Duration // Zero if a bond reduction hasn't been started
+		userDistributeAge time.Duration // Zero if a user distribution hasn't been started
+		allowed           []Action
+		waiting           map[Action]time.Duration // Actions that become allowed after waiting, and how long from now
+	}{
+		{
+			name:    "legacy minipool in the queue",
+			status:  types.Initialized,
+			version: 2,
+			allowed: []Action{Action_Dissolve},
+		},
+		{
+			name:    "minipool in the queue",
+			status:  types.Initialized,
+			version: 3,
+			allowed: []Action{},
+		},
+		{
+			name:      "prelaunch in the scrub period",
+			status:    types.Prelaunch,
+			version:   3,
+			statusAge: time.Hour,
+			allowed:   []Action{},
+			waiting: map[Action]time.Duration{
+				Action_Stake:    11 * time.Hour,
+				Action_Dissolve: 71 * time.Hour,
+			},
+		},
+		{
+			name:      "prelaunch after the scrub period",
+			status:    types.Prelaunch,
+			version:   3,
+			statusAge: 13 * time.Hour,
+			allowed:   []Action{Action_Stake},
+			waiting: map[Action]time.Duration{
+				Action_Dissolve: 59 * time.Hour,
+			},
+		},
+		{
+			name:      "vacant minipool after the promotion scrub period",
+			status:    types.Prelaunch,
+			version:   3,
+			statusAge: 73 * time.Hour,
+			vacant:    true,
+			allowed:   []Action{Action_Promote, Action_Dissolve},
+		},
+		{
+			name:    "dissolved",
+			status:  types.Dissolved,
+			version: 3,
+			allowed: []Action{Action_Close},
+		},
+		{
+			name:    "staking with skimmed rewards",
+			status:  types.Staking,
+			version: 3,
+			balance: eth.EthToWei(0.5),
+			bond:    eth.EthToWei(16),
+			allowed: []Action{Action_DistributeRewards, Action_BeginBondReduction},
+		},
+		{
+			name:    "staking with an 8 ETH bond",
+			status:  types.Staking,
+			version: 3,
+			balance: eth.EthToWei(0.5),
+			bond:    eth.EthToWei(8),
+			allowed: []Action{Action_DistributeRewards},
+		},
+		{
+			name:    "staking after an exit",
+			status:  types.Staking,
+			version: 3,
+			balance: eth.EthToWei(32),
+			bond:    eth.EthToWei(8),
+			allowed: []Action{Action_DistributeBalance, Action_BeginUserDistribute},
+		},
+		{
+			name:              "user distribution waiting",
+			status:            types.Staking,
+			version:           3,
+			balance:           eth.EthToWei(32),
+			bond:              eth.EthToWei(8),
+			userDistributeAge: 89 * time.Hour,
+			allowed:           []Action{Action_DistributeBalance},
+			waiting: map[Action]time.Duration{
+				Action_BeginUserDistribute: 3 * time.Hour,
+				Action_UserDistribute:      time.Hour,
+			},
+		},
+		{
+			name:              "user distribution open",
+			status:            types.Staking,
+			version:           3,
+			balance:           eth.EthToWei(32),
+			bond:              eth.EthToWei(8),
+			userDistributeAge: 91 * time.Hour,
+			allowed:           []Action{Action_DistributeBalance, Action_UserDistribute},
+			waiting: map[Action]time.Duration{
+				Action_BeginUserDistribute: time.Hour,
+			},
+		},
+		{
+			name:          "bond reduction window open",
+			status:        types.Staking,
+			version:       3,
+			balance:       eth.EthToWei(0.5),
+			bond:          eth.EthToWei(16),
+			reduceBondAge: 13 * time.Hour,
+			allowed:       []Action{Action_DistributeRewards, Action_ReduceBond},
+			waiting: map[Action]time.Duration{
+				Action_BeginBondReduction: time.Hour,
+			},
+		},
+		{
+			name:          "bond reduction window expired",
+			status:        types.Staking,
+			version:       3,
+			balance:       eth.EthToWei(0.5),
+			bond:          eth.EthToWei(16),
+			reduceBondAge: 15 * time.Hour,
+			allowed:       []Action{Action_DistributeRewards, Action_BeginBondReduction},
+		},
+		{
+			name:    "legacy minipool staking",
+			status:  types.Staking,
+			version: 2,
+			balance: eth.EthToWei(0.5),
+			bond:    eth.EthToWei(16),
+			allowed: []Action{Action_DistributeBalance},
+		},
+		{
+			name:      "finalised",
+			status:    types.Staking,
+			version:   3,
+			balance:   eth.EthToWei(0.5),
+			bond:      eth.EthToWei(8),
+			finalised: true,
+			allowed:   []Action{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mpd := &state.NativeMinipoolDetails{
+				Status:             test.status,
+				Version:            test.version,
+				StatusTime:         big.NewInt(now.Add(-test.statusAge).Unix()),
+				Balance:            big.NewInt(0),
+				NodeRefundBalance:  big.NewInt(0),
+				NodeDepositBalance: eth.EthToWei(8),
+				ReduceBondTime:     big.NewInt(0),
+				IsVacant:           test.vacant,
+				Finalised:          test.finalised,
+			}
+			if test.balance != nil {
+				mpd.Balance = test.balance
+			}
+			if test.bond != nil {
+				mpd.NodeDepositBalance = test.bond
+			}
+			if test.reduceBondAge != 0 {
+				mpd.ReduceBondTime = big.NewInt(now.Add(-test.reduceBondAge).Unix())
+			}
+			var userDistributeTime time.Time
+			if test.userDistributeAge != 0 {
+				userDistributeTime = now.Add(-test.userDistributeAge)
+			}
+
+			plan := NewPlan(mpd, settings, userDistributeTime, now)
+			allowed := map[Action]bool{}
+			for _, action := range test.allowed {
+				allowed[action] = true
+			}
+			for _, action := range Actions {
+				actionPlan := plan.GetAction(action)
+				if actionPlan.Allowed != allowed[action] {
+					t.Errorf("%s: expected allowed to be %t, got %t (%s)", action, allowed[action], actionPlan.Allowed, actionPlan.Reason)
+				}
+				wait, isWaiting := test.waiting[action]
+				if !isWaiting {
+					if !actionPlan.Allowed && !actionPlan.EarliestTime.IsZero() {
+						t.Errorf("%s: unexpected earliest time %s", action, actionPlan.EarliestTime)
+					}
+					continue
+				}
+				if expected := now.Add(wait); !actionPlan.EarliestTime.Equal(expected) {
+					t.Errorf("%s: expected earliest time %s, got %s", action, expected, actionPlan.EarliestTime)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+	"golang.org/x/sync/errgroup"
+)
+
+// Settings
+const (
+	queueThreadLimit        int    = 6
+	DefaultMaxQueueDeposits uint64 = 100000
+)
+
+// The deposit pool settings that control queue assignments
+type QueueSettings struct {
+	AssignDepositsEnabled bool     `json:"assign_deposits_enabled"`
+	MaximumAssignments    uint64   `json:"maximum_assignments"`
+	SocialisedAssignments uint64   `json:"socialised_assignments"`
+	VariableDepositAmount *big.Int `json:"variable_deposit_amount"` // The ETH each queued minipool is assigned
+}
+
+// An assumption about future rETH deposits: one deposit of the given amount every interval
+type DepositRate struct {
+	Amount   *big.Int      `json:"amount"`
+	Interval time.Duration `json:"interval"`
+}
+
+// The minipool queue and deposit pool at a point in time
+type QueueSnapshot struct {
+	Time               time.Time        `json:"time"`
+	Minipools          []common.Address `json:"minipools"` // In queue order
+	DepositPoolBalance *big.Int         `json:"deposit_pool_balance"`
+	Settings           QueueSettings    `json:"settings"`
+}
+
+// When a queued minipool is predicted to be assigned
+type QueueAssignment struct {
+	MinipoolAddress common.Address `json:"minipool_address"`
+	Position        uint64         `json:"position"` // 0-indexed
+	Assigned        bool           `json:"assigned"` // False if the simulation ran out of deposits before getting to it
+	DepositNumber   uint64         `json:"deposit_number"`
+	ETA             time.Time      `json:"eta"`
+}
+
+// A minipool whose predicted assignment changes because of a new deposit
+type QueueEtaChange struct {
+	MinipoolAddress common.Address  `json:"minipool_address"`
+	Before          QueueAssignment `json:"before"`
+	After           QueueAssignment `json:"after"`
+}
+
+// Load the minipool queue and the deposit pool state
+func LoadQueue(rp *rocketpool.RocketPool, contracts *state.NetworkContracts) (*QueueSnapshot, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	header, err := rp.Client.HeaderByNumber(context.Background(), contracts.ElBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %s: %w", contracts.ElBlockNumber.String(), err)
+	}
+	snapshot := &QueueSnapshot{
+		Time: time.Unix(int64(header.Time), 0),
+	}
+
+	// Settings and balances
+	var length *big.Int
+	var maximumAssignments *big.Int
+	var socialisedAssignments *big.Int
+	contracts.Multicaller.AddCall(contracts.RocketMinipoolQueue, &length, "getTotalLength")
+	contracts.Multicaller.AddCall(contracts.RocketDepositPool, &snapshot.DepositPoolBalance, "getBalance")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsDeposit, &snapshot.Settings.AssignDepositsEnabled, "getAssignDepositsEnabled")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsDeposit, &maximumAssignments, "getMaximumDepositAssignments")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsDeposit, &socialisedAssignments, "getMaximumDepositSocialisedAssignments")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &snapshot.Settings.VariableDepositAmount, "getVariableDepositAmount")
+	_, err = contracts.Multicaller.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	snapshot.Settings.MaximumAssignments = maximumAssignments.Uint64()
+	snapshot.Settings.SocialisedAssignments = socialisedAssignments.Uint64()
+
+	// Queue contents
+	count := length.Uint64()
+	snapshot.Minipools = make([]common.Address, count)
+	var wg errgroup.Group
+	wg.SetLimit(queueThreadLimit)
+	for i := uint64(0); i < count; i++ {
+		i := i
+		wg.Go(func() error {
+			address, err := minipool.GetQueueMinipoolAtPosition(rp, i, opts)
+			if err != nil {
+				return err
+			}
+			snapshot.Minipools[i] = address
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting queued minipools: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// Simulate deposits at the given rate until every queued minipool is assigned or maxDeposits deposits have been made.
+// Assignments mirror the deposit pool: each deposit assigns the socialised count plus one minipool per full variable
+// deposit amount it brings in, limited by the pool balance and the maximum assignments per deposit.
+func (q *QueueSnapshot) Simulate(rate DepositRate, maxDeposits uint64) []QueueAssignment {
+	return q.simulate(nil, rate, maxDeposits)
+}
+
+// Get how a new deposit made now would change the predicted assignment of each queued minipool
+func (q *QueueSnapshot) GetDepositImpact(amount *big.Int, rate DepositRate, maxDeposits uint64) []QueueEtaChange {
+	before := q.simulate(nil, rate, maxDeposits)
+	after := q.simulate(amount, rate, maxDeposits)
+	changes := []QueueEtaChange{}
+	for i := range before {
+		if before[i].Assigned != after[i].Assigned || !before[i].ETA.Equal(after[i].ETA) {
+			changes = append(changes, QueueEtaChange{
+				MinipoolAddress: before[i].MinipoolAddress,
+				Before:          before[i],
+				After:           after[i],
+			})
+		}
+	}
+	return changes
+}
+
+// Run the simulation, optionally with an extra deposit made at the snapshot time (deposit number 0)
+func (q *QueueSnapshot) simulate(initialDeposit *big.Int, rate DepositRate, maxDeposits uint64) []QueueAssignment {
+	assignments := make([]QueueAssignment, len(q.Minipools))
+	for i, address := range q.Minipools {
+		assignments[i] = QueueAssignment{
+			MinipoolAddress: address,
+			Position:        uint64(i),
+		}
+	}
+	variableAmount := q.Settings.VariableDepositAmount
+	if !q.Settings.AssignDepositsEnabled || variableAmount == nil || variableAmount.Sign() == 0 {
+		return assignments
+	}
+	if maxDeposits == 0 {
+		maxDeposits = DefaultMaxQueueDeposits
+	}
+	hasRate := rate.Amount != nil && rate.Amount.Sign() > 0 && rate.Interval > 0
+
+	balance := big.NewInt(0).Set(q.DepositPoolBalance)
+	next := 0
+	for depositNumber := uint64(0); depositNumber <= maxDeposits && next < len(assignments); depositNumber++ {
+		var amount *big.Int
+		if depositNumber == 0 {
+			if initialDeposit == nil {
+				continue
+			}
+			amount = initialDeposit
+		} else {
+			if !hasRate {
+				break
+			}
+			amount = rate.Amount
+		}
+		balance.Add(balance, amount)
+
+		// Work out how many minipools this deposit assigns
+		count := big.NewInt(0).Div(amount, variableAmount)
+		count.Add(count, big.NewInt(int64(q.Settings.SocialisedAssignments)))
+		if ethCount := big.NewInt(0).Div(balance, variableAmount); count.Cmp(ethCount) > 0 {
+			count = ethCount
+		}
+		if maximum := big.NewInt(0).SetUint64(q.Settings.MaximumAssignments); count.Cmp(maximum) > 0 {
+			count = maximum
+		}
+
+		eta := q.Time.Add(time.Duration(depositNumber) * rate.Interval)
+		for i := uint64(0); i < count.Uint64() && next < len(assignments); i++ {
+			assignments[next].Assigned = true
+			assignments[next].DepositNumber = depositNumber
+			assignments[next].ETA = eta
+			balance.Sub(balance, variableAmount)
+			next++
+		}
+	}
+	return assignments
+}
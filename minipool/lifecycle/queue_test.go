@@ -0,0 +1,177 @@
+package lifecycle
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestQueueSimulate(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	minipools := []common.Address{
+		common.HexToAddress("0x2222222222222222222222222222222222222221"),
+		common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		common.HexToAddress("0x2222222222222222222222222222222222222223"),
+		common.HexToAddress("0x2222222222222222222222222222222222222224"),
+		common.HexToAddress("0x2222222222222222222222222222222222222225"),
+	}
+	settings := QueueSettings{
+		AssignDepositsEnabled: true,
+		MaximumAssignments:    90,
+		SocialisedAssignments: 2,
+		VariableDepositAmount: eth.EthToWei(31),
+	}
+	hourly := func(amount float64) DepositRate {
+		return DepositRate{Amount: eth.EthToWei(amount), Interval: time.Hour}
+	}
+
+	tests := []struct {
+		name        string
+		balance     *big.Int
+		settings    func(*QueueSettings)
+		rate        DepositRate
+		maxDeposits uint64
+		expected    []int64 // The hour each minipool is assigned in, or -1 if it isn't
+	}{
+		{
+			name:     "no deposits",
+			balance:  big.NewInt(0),
+			expected: []int64{-1, -1, -1, -1, -1},
+		},
+		{
+			name:     "assignments limited by the pool balance",
+			balance:  big.NewInt(0),
+			rate:     hourly(40),
+			expected: []int64{1, 2, 3, 4, 4},
+		},
+		{
+			name:     "socialised assignments from the existing balance",
+			balance:  eth.EthToWei(100),
+			rate:     hourly(1),
+			expected: []int64{1, 1, 2, 24, 55},
+		},
+		{
+			name:        "out of deposits",
+			balance:     eth.EthToWei(100),
+			rate:        hourly(1),
+			maxDeposits: 30,
+			expected:    []int64{1, 1, 2, 24, -1},
+		},
+		{
+			name:     "maximum assignments per deposit",
+			balance:  big.NewInt(0),
+			settings: func(s *QueueSettings) { s.MaximumAssignments = 1 },
+			rate:     hourly(100),
+			expected: []int64{1, 2, 3, 4, 5},
+		},
+		{
+			name:     "assignments disabled",
+			balance:  eth.EthToWei(1000),
+			settings: func(s *QueueSettings) { s.AssignDepositsEnabled = false },
+			rate:     hourly(100),
+			expected: []int64{-1, -1, -1, -1, -1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			queue := &QueueSnapshot{
+				Time:               now,
+				Minipools:          minipools,
+				DepositPoolBalance: test.balance,
+				Settings:           settings,
+			}
+			if test.settings != nil {
+				test.settings(&queue.Settings)
+			}
+			assignments := queue.Simulate(test.rate, test.maxDeposits)
+			if len(assignments) != len(test.expected) {
+				t.Fatalf("expected %d assignments, got %d", len(test.expected), len(assignments))
+			}
+			for i, assignment := range assignments {
+				if assignment.MinipoolAddress != queue.Minipools[i] || assignment.Position != uint64(i) {
+					t.Errorf("assignment %d is for minipool %s at position %d", i, assignment.MinipoolAddress.Hex(), assignment.Position)
+				}
+				hour := test.expected[i]
+				if hour < 0 {
+					if assignment.Assigned {
+						t.Errorf("minipool %d: expected to be unassigned, got deposit %d", i, assignment.DepositNumber)
+					}
+					continue
+				}
+				if !assignment.Assigned || assignment.DepositNumber != uint64(hour) || !assignment.ETA.Equal(now.Add(time.Duration(hour)*time.Hour)) {
+					t.Errorf("minipool %d: expected deposit %d, got assigned %t at deposit %d (%s)", i, hour, assignment.Assigned, assignment.DepositNumber, assignment.ETA)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueGetDepositImpact(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	minipools := []common.Address{
+		common.HexToAddress("0x2222222222222222222222222222222222222221"),
+		common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		common.HexToAddress("0x2222222222222222222222222222222222222223"),
+		common.HexToAddress("0x2222222222222222222222222222222222222224"),
+		common.HexToAddress("0x2222222222222222222222222222222222222225"),
+	}
+	queue := &QueueSnapshot{
+		Time:               now,
+		Minipools:          minipools,
+		DepositPoolBalance: big.NewInt(0),
+		Settings: QueueSettings{
+			AssignDepositsEnabled: true,
+			MaximumAssignments:    90,
+			SocialisedAssignments: 2,
+			VariableDepositAmount: eth.EthToWei(31),
+		},
+	}
+	rate := DepositRate{Amount: eth.EthToWei(40), Interval: time.Hour}
+
+	tests := []struct {
+		name     string
+		amount   *big.Int
+		expected map[int]time.Duration // Queue position to the new ETA, from now
+	}{
+		{
+			name:     "too small to assign anything",
+			amount:   eth.EthToWei(1),
+			expected: map[int]time.Duration{},
+		},
+		{
+			name:   "assigns two minipools straight away",
+			amount: eth.EthToWei(62),
+			expected: map[int]time.Duration{
+				0: 0,
+				1: 0,
+				2: time.Hour,
+				3: 2 * time.Hour,
+				4: 3 * time.Hour,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes := queue.GetDepositImpact(test.amount, rate, 0)
+			if len(changes) != len(test.expected) {
+				t.Fatalf("expected %d changes, got %d", len(test.expected), len(changes))
+			}
+			for _, change := range changes {
+				position := int(change.After.Position)
+				eta, exists := test.expected[position]
+				if !exists {
+					t.Errorf("unexpected change for position %d", position)
+					continue
+				}
+				if change.MinipoolAddress != queue.Minipools[position] || !change.After.ETA.Equal(now.Add(eta)) {
+					t.Errorf("position %d: expected ETA %s, got %s", position, now.Add(eta), change.After.ETA)
+				}
+			}
+		})
+	}
+}
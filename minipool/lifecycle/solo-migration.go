@@ -0,0 +1,296 @@
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/settings/protocol"
+	"github.com/rocket-pool/rocketpool-go/settings/trustednode"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/validator"
+)
+
+// The minimum Beacon balance a solo validator needs to migrate
+var soloMigrationMinimumBalance = eth.EthToWei(32)
+
+// The steps of a solo validator migration
+type SoloMigrationStep string
+
+const (
+	SoloMigrationStep_CreateMinipool              SoloMigrationStep = "createMinipool"
+	SoloMigrationStep_ChangeWithdrawalCredentials SoloMigrationStep = "changeWithdrawalCredentials"
+	SoloMigrationStep_WaitForScrubCheck           SoloMigrationStep = "waitForScrubCheck"
+	SoloMigrationStep_Promote                     SoloMigrationStep = "promote"
+	SoloMigrationStep_Complete                    SoloMigrationStep = "complete"
+	SoloMigrationStep_Failed                      SoloMigrationStep = "failed"
+)
+
+// The parameters of a solo validator migration.
+// This is all that needs to be persisted to resume a migration; everything else is read from the chain and the Beacon view.
+type SoloMigrationConfig struct {
+	NodeAddress    common.Address        `json:"node_address"`
+	Pubkey         types.ValidatorPubkey `json:"pubkey"`
+	Salt           *big.Int              `json:"salt"`
+	BondAmount     *big.Int              `json:"bond_amount"`
+	MinimumNodeFee float64               `json:"minimum_node_fee"`
+}
+
+// The state of the solo validator on the Beacon chain, as supplied by the caller
+type SoloValidatorBeaconView struct {
+	Exists                bool        `json:"exists"`
+	Active                bool        `json:"active"`
+	Slashed               bool        `json:"slashed"`
+	WithdrawalCredentials common.Hash `json:"withdrawal_credentials"`
+	Balance               *big.Int    `json:"balance"` // In wei
+}
+
+// Where a solo validator migration is, and what's needed to continue it
+type SoloMigrationStatus struct {
+	Step            SoloMigrationStep `json:"step"`
+	MinipoolAddress common.Address    `json:"minipool_address"`
+	EarliestTime    time.Time         `json:"earliest_time"` // Set when waiting for the scrub check
+	Problems        []string          `json:"problems"`      // Set when the migration can't continue
+}
+
+// A solo validator migration into a vacant minipool
+type SoloMigration struct {
+	Config          SoloMigrationConfig     `json:"config"`
+	Beacon          SoloValidatorBeaconView `json:"beacon"`
+	ExpectedAddress common.Address          `json:"expected_address"`
+	NodeSalt        common.Hash             `json:"node_salt"`
+	BlockTime       time.Time               `json:"block_time"` // The time of the block the chain state was loaded from
+
+	// Chain state
+	NodeRegistered         bool                 `json:"node_registered"`
+	VacantMinipoolsEnabled bool                 `json:"vacant_minipools_enabled"`
+	PromotionScrubPeriod   time.Duration        `json:"promotion_scrub_period"`
+	PubkeyMinipool         common.Address       `json:"pubkey_minipool"` // The minipool already using the pubkey, if any
+	MinipoolExists         bool                 `json:"minipool_exists"`
+	MinipoolStatus         types.MinipoolStatus `json:"minipool_status"`
+	MinipoolStatusTime     time.Time            `json:"minipool_status_time"`
+	MinipoolVacant         bool                 `json:"minipool_vacant"`
+
+	rp *rocketpool.RocketPool
+}
+
+// Load a solo validator migration from its config and the validator's current Beacon state.
+// Call this again after each transaction or Beacon change (or after a restart) to get the latest status.
+func NewSoloMigration(rp *rocketpool.RocketPool, config SoloMigrationConfig, beacon SoloValidatorBeaconView, opts *bind.CallOpts) (*SoloMigration, error) {
+	migration := &SoloMigration{
+		Config: config,
+		Beacon: beacon,
+		rp:     rp,
+	}
+
+	// The salt is a uint256 on-chain
+	if config.Salt == nil || config.Salt.Sign() < 0 || config.Salt.BitLen() > 256 {
+		return nil, fmt.Errorf("salt %s is not a valid uint256", config.Salt.String())
+	}
+	migration.NodeSalt = utils.GetNodeSalt(config.NodeAddress, config.Salt)
+
+	var blockNumber *big.Int
+	if opts != nil {
+		blockNumber = opts.BlockNumber
+	}
+	header, err := rp.Client.HeaderByNumber(context.Background(), blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error getting block header: %w", err)
+	}
+	migration.BlockTime = time.Unix(int64(header.Time), 0)
+	migration.ExpectedAddress, err = minipool.GetExpectedAddress(rp, config.NodeAddress, config.Salt, opts)
+	if err != nil {
+		return nil, err
+	}
+	migration.NodeRegistered, err = node.GetNodeExists(rp, config.NodeAddress, opts)
+	if err != nil {
+		return nil, err
+	}
+	migration.VacantMinipoolsEnabled, err = protocol.GetVacantMinipoolsEnabled(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+	promotionScrubPeriod, err := trustednode.GetPromotionScrubPeriod(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+	migration.PromotionScrubPeriod = time.Duration(promotionScrubPeriod) * time.Second
+	migration.PubkeyMinipool, err = minipool.GetMinipoolByPubkey(rp, config.Pubkey, opts)
+	if err != nil {
+		return nil, err
+	}
+	migration.MinipoolExists, err = minipool.GetMinipoolExists(rp, migration.ExpectedAddress, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Minipool details
+	if migration.MinipoolExists {
+		mp, err := minipool.NewMinipool(rp, migration.ExpectedAddress, opts)
+		if err != nil {
+			return nil, err
+		}
+		statusDetails, err := mp.GetStatusDetails(opts)
+		if err != nil {
+			return nil, err
+		}
+		migration.MinipoolStatus = statusDetails.Status
+		migration.MinipoolStatusTime = statusDetails.StatusTime
+		migration.MinipoolVacant = statusDetails.IsVacant
+	}
+
+	return migration, nil
+}
+
+// Get the status of the migration as of the given time
+func (m *SoloMigration) GetStatus(now time.Time) SoloMigrationStatus {
+	status := SoloMigrationStatus{
+		MinipoolAddress: m.ExpectedAddress,
+		Problems:        []string{},
+	}
+
+	// Before the minipool is created
+	if !m.MinipoolExists {
+		status.Problems = m.getCreationProblems()
+		if len(status.Problems) > 0 {
+			status.Step = SoloMigrationStep_Failed
+		} else {
+			status.Step = SoloMigrationStep_CreateMinipool
+		}
+		return status
+	}
+
+	switch {
+	case m.MinipoolStatus == types.Dissolved:
+		status.Step = SoloMigrationStep_Failed
+		status.Problems = append(status.Problems, "the minipool was dissolved by the Oracle DAO's scrub check")
+	case m.MinipoolStatus == types.Staking && !m.MinipoolVacant:
+		status.Step = SoloMigrationStep_Complete
+	case m.MinipoolStatus == types.Prelaunch && m.MinipoolVacant:
+		if m.Beacon.WithdrawalCredentials != validator.GetMinipoolWithdrawalCredentials(m.ExpectedAddress) {
+			// This has to happen before the scrub check ends or the minipool will be dissolved
+			status.Step = SoloMigrationStep_ChangeWithdrawalCredentials
+			status.EarliestTime = now
+			break
+		}
+		promotionTime := m.MinipoolStatusTime.Add(m.PromotionScrubPeriod)
+		if now.Before(promotionTime) {
+			status.Step = SoloMigrationStep_WaitForScrubCheck
+			status.EarliestTime = promotionTime
+		} else {
+			status.Step = SoloMigrationStep_Promote
+		}
+	default:
+		status.Step = SoloMigrationStep_Failed
+		status.Problems = append(status.Problems, fmt.Sprintf("minipool %s is in an unexpected state (%s, vacant = %t)", m.ExpectedAddress.Hex(), m.MinipoolStatus.String(), m.MinipoolVacant))
+	}
+	return status
+}
+
+// Estimate the gas of CreateMinipool
+func (m *SoloMigration) EstimateCreateMinipoolGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkStep(SoloMigrationStep_CreateMinipool); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return node.EstimateCreateVacantMinipoolGas(m.rp, m.Config.BondAmount, m.Config.MinimumNodeFee, m.Config.Pubkey, m.Config.Salt, m.ExpectedAddress, m.Beacon.Balance, opts)
+}
+
+// Create the vacant minipool the solo validator will migrate into
+func (m *SoloMigration) CreateMinipool(opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkStep(SoloMigrationStep_CreateMinipool); err != nil {
+		return common.Hash{}, err
+	}
+	tx, err := node.CreateVacantMinipool(m.rp, m.Config.BondAmount, m.Config.MinimumNodeFee, m.Config.Pubkey, m.Config.Salt, m.ExpectedAddress, m.Beacon.Balance, opts)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return tx.Hash(), nil
+}
+
+// Estimate the gas of Promote
+func (m *SoloMigration) EstimatePromoteGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	mp, err := m.getPromotableMinipool()
+	if err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return mp.EstimatePromoteGas(opts)
+}
+
+// Promote the vacant minipool once the scrub check has passed
+func (m *SoloMigration) Promote(opts *bind.TransactOpts) (common.Hash, error) {
+	mp, err := m.getPromotableMinipool()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return mp.Promote(opts)
+}
+
+// Get the reasons the vacant minipool can't be created, if any
+func (m *SoloMigration) getCreationProblems() []string {
+	problems := []string{}
+	if !m.NodeRegistered {
+		problems = append(problems, fmt.Sprintf("node %s is not registered", m.Config.NodeAddress.Hex()))
+	}
+	if !m.VacantMinipoolsEnabled {
+		problems = append(problems, "vacant minipools are currently disabled")
+	}
+	if m.PubkeyMinipool != (common.Address{}) {
+		problems = append(problems, fmt.Sprintf("validator %s is already used by minipool %s", m.Config.Pubkey.Hex(), m.PubkeyMinipool.Hex()))
+	}
+
+	// Beacon checks
+	if !m.Beacon.Exists {
+		problems = append(problems, fmt.Sprintf("validator %s does not exist on the Beacon chain", m.Config.Pubkey.Hex()))
+		return problems
+	}
+	if !m.Beacon.Active {
+		problems = append(problems, "the validator is not active")
+	}
+	if m.Beacon.Slashed {
+		problems = append(problems, "the validator has been slashed")
+	}
+	if m.Beacon.Balance == nil || m.Beacon.Balance.Cmp(soloMigrationMinimumBalance) < 0 {
+		problems = append(problems, "the validator's balance is below 32 ETH")
+	}
+
+	// The credentials can still be BLS, or can already point to the minipool's future address
+	credentials := m.Beacon.WithdrawalCredentials
+	if credentials == (common.Hash{}) {
+		problems = append(problems, "the validator's withdrawal credentials are empty")
+	} else if credentials[0] != 0x00 && credentials != validator.GetMinipoolWithdrawalCredentials(m.ExpectedAddress) {
+		problems = append(problems, fmt.Sprintf("the validator's withdrawal credentials (%s) are neither BLS credentials nor the minipool's address", credentials.Hex()))
+	}
+	return problems
+}
+
+// Make sure the migration is on the given step as of the block the migration was loaded from
+func (m *SoloMigration) checkStep(step SoloMigrationStep) error {
+	status := m.GetStatus(m.BlockTime)
+	if status.Step != step {
+		return fmt.Errorf("the migration is on step %s, not %s", status.Step, step)
+	}
+	return nil
+}
+
+// Get the minipool binding if it's ready to be promoted
+func (m *SoloMigration) getPromotableMinipool() (*minipool.MinipoolAdapter, error) {
+	if err := m.checkStep(SoloMigrationStep_Promote); err != nil {
+		return nil, err
+	}
+	mp, err := minipool.NewMinipoolAdapterFromAddress(m.rp, m.ExpectedAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !mp.SupportsPromote() {
+		return nil, fmt.Errorf("minipool %s does not support promotion", m.ExpectedAddress.Hex())
+	}
+	return mp, nil
+}
@@ -0,0 +1,214 @@
+package lifecycle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/validator"
+)
+
+func TestSoloMigrationGetStatus(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	minipoolAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	minipoolCredentials := validator.GetMinipoolWithdrawalCredentials(minipoolAddress)
+	blsCredentials := common.HexToHash("0x00aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+
+	tests := []struct {
+		name                 string
+		minipoolExists       bool
+		status               types.MinipoolStatus
+		vacant               bool
+		statusAge            time.Duration
+		credentials          common.Hash
+		vacantDisabled       bool
+		expectedStep         SoloMigrationStep
+		expectedEarliestTime time.Time
+		expectProblems       bool
+	}{
+		{name: "ready to create", credentials: blsCredentials, expectedStep: SoloMigrationStep_CreateMinipool},
+		{name: "can't create", credentials: blsCredentials, vacantDisabled: true, expectedStep: SoloMigrationStep_Failed, expectProblems: true},
+		{
+			name:           "credentials still BLS",
+			minipoolExists: true, status: types.Prelaunch, vacant: true, statusAge: time.Hour,
+			credentials:          blsCredentials,
+			expectedStep:         SoloMigrationStep_ChangeWithdrawalCredentials,
+			expectedEarliestTime: now,
+		},
+		{
+			name:           "in the scrub check",
+			minipoolExists: true, status: types.Prelaunch, vacant: true, statusAge: time.Hour,
+			credentials:          minipoolCredentials,
+			expectedStep:         SoloMigrationStep_WaitForScrubCheck,
+			expectedEarliestTime: now.Add(71 * time.Hour),
+		},
+		{
+			name:           "scrub check passed",
+			minipoolExists: true, status: types.Prelaunch, vacant: true, statusAge: 72 * time.Hour,
+			credentials:  minipoolCredentials,
+			expectedStep: SoloMigrationStep_Promote,
+		},
+		{
+			name:           "promoted",
+			minipoolExists: true, status: types.Staking, statusAge: 80 * time.Hour,
+			credentials:  minipoolCredentials,
+			expectedStep: SoloMigrationStep_Complete,
+		},
+		{
+			name:           "dissolved",
+			minipoolExists: true, status: types.Dissolved, vacant: true,
+			credentials:    minipoolCredentials,
+			expectedStep:   SoloMigrationStep_Failed,
+			expectProblems: true,
+		},
+		{
+			name:           "not vacant",
+			minipoolExists: true, status: types.Prelaunch,
+			credentials:    minipoolCredentials,
+			expectedStep:   SoloMigrationStep_Failed,
+			expectProblems: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migration := &SoloMigration{
+				Config: SoloMigrationConfig{
+					NodeAddress: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+					Pubkey:      types.ValidatorPubkey{0x01},
+				},
+				Beacon: SoloValidatorBeaconView{
+					Exists:                true,
+					Active:                true,
+					WithdrawalCredentials: test.credentials,
+					Balance:               eth.EthToWei(32),
+				},
+				ExpectedAddress:        minipoolAddress,
+				BlockTime:              now,
+				NodeRegistered:         true,
+				VacantMinipoolsEnabled: !test.vacantDisabled,
+				PromotionScrubPeriod:   72 * time.Hour,
+				MinipoolExists:         test.minipoolExists,
+				MinipoolStatus:         test.status,
+				MinipoolStatusTime:     now.Add(-test.statusAge),
+				MinipoolVacant:         test.vacant,
+			}
+
+			status := migration.GetStatus(now)
+			if status.Step != test.expectedStep {
+				t.Errorf("expected step %s but got %s (problems: %v)", test.expectedStep, status.Step, status.Problems)
+			}
+			if !status.EarliestTime.Equal(test.expectedEarliestTime) {
+				t.Errorf("expected an earliest time of %s but got %s", test.expectedEarliestTime, status.EarliestTime)
+			}
+			if (len(status.Problems) > 0) != test.expectProblems {
+				t.Errorf("unexpected problems: %v", status.Problems)
+			}
+			if status.MinipoolAddress != minipoolAddress {
+				t.Errorf("expected minipool %s but got %s", minipoolAddress.Hex(), status.MinipoolAddress.Hex())
+			}
+
+			// Transactions are checked against the block time rather than the clock
+			if err := migration.checkStep(test.expectedStep); err != nil {
+				t.Errorf("unexpected error checking the step: %v", err)
+			}
+		})
+	}
+}
+
+func TestSoloMigrationGetCreationProblems(t *testing.T) {
+	minipoolAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	pubkeyMinipool := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	tests := []struct {
+		name     string
+		modify   func(m *SoloMigration)
+		expected []string
+	}{
+		{name: "ready", expected: []string{}},
+		{
+			name: "network checks",
+			modify: func(m *SoloMigration) {
+				m.NodeRegistered = false
+				m.VacantMinipoolsEnabled = false
+				m.PubkeyMinipool = pubkeyMinipool
+			},
+			expected: []string{
+				"node 0x1111111111111111111111111111111111111111 is not registered",
+				"vacant minipools are currently disabled",
+				"validator " + types.ValidatorPubkey{0x01}.Hex() + " is already used by minipool " + pubkeyMinipool.Hex(),
+			},
+		},
+		{
+			name:     "validator doesn't exist",
+			modify:   func(m *SoloMigration) { m.Beacon = SoloValidatorBeaconView{} },
+			expected: []string{"validator " + types.ValidatorPubkey{0x01}.Hex() + " does not exist on the Beacon chain"},
+		},
+		{
+			name: "validator checks",
+			modify: func(m *SoloMigration) {
+				m.Beacon.Active = false
+				m.Beacon.Slashed = true
+				m.Beacon.Balance = eth.EthToWei(31.9)
+			},
+			expected: []string{
+				"the validator is not active",
+				"the validator has been slashed",
+				"the validator's balance is below 32 ETH",
+			},
+		},
+		{
+			name:     "missing balance",
+			modify:   func(m *SoloMigration) { m.Beacon.Balance = nil },
+			expected: []string{"the validator's balance is below 32 ETH"},
+		},
+		{
+			name: "credentials already point to the minipool",
+			modify: func(m *SoloMigration) {
+				m.Beacon.WithdrawalCredentials = validator.GetMinipoolWithdrawalCredentials(minipoolAddress)
+			},
+			expected: []string{},
+		},
+		{
+			name:     "empty credentials",
+			modify:   func(m *SoloMigration) { m.Beacon.WithdrawalCredentials = common.Hash{} },
+			expected: []string{"the validator's withdrawal credentials are empty"},
+		},
+		{
+			name: "credentials point somewhere else",
+			modify: func(m *SoloMigration) {
+				m.Beacon.WithdrawalCredentials = validator.GetMinipoolWithdrawalCredentials(pubkeyMinipool)
+			},
+			expected: []string{"the validator's withdrawal credentials (" + validator.GetMinipoolWithdrawalCredentials(pubkeyMinipool).Hex() + ") are neither BLS credentials nor the minipool's address"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			migration := &SoloMigration{
+				Config: SoloMigrationConfig{
+					NodeAddress: common.HexToAddress("0x1111111111111111111111111111111111111111"),
+					Pubkey:      types.ValidatorPubkey{0x01},
+				},
+				Beacon: SoloValidatorBeaconView{
+					Exists:                true,
+					Active:                true,
+					WithdrawalCredentials: common.HexToHash("0x00aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+					Balance:               eth.EthToWei(32),
+				},
+				ExpectedAddress:        minipoolAddress,
+				NodeRegistered:         true,
+				VacantMinipoolsEnabled: true,
+			}
+			if test.modify != nil {
+				test.modify(migration)
+			}
+			if problems := migration.getCreationProblems(); !reflect.DeepEqual(problems, test.expected) {
+				t.Errorf("expected problems %v but got %v", test.expected, problems)
+			}
+		})
+	}
+}
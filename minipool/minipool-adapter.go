@@ -0,0 +1,216 @@
+package minipool
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// Returned (wrapped) by the adapter when a minipool's delegate doesn't support the requested function
+var ErrUnsupportedByMinipoolVersion = errors.New("not supported by this minipool version")
+
+// The optional capabilities of minipool delegates.
+// The adapter routes on these instead of version numbers, so a future delegate binding only needs to implement the
+// methods it supports (and be added to NewMinipoolFromVersion) for the adapter to use it.
+type rewardsOnlyDistributor interface {
+	EstimateDistributeBalanceGas(rewardsOnly bool, opts *bind.TransactOpts) (rocketpool.GasInfo, error)
+	DistributeBalance(rewardsOnly bool, opts *bind.TransactOpts) (common.Hash, error)
+}
+type legacyDistributor interface {
+	EstimateDistributeBalanceGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error)
+	DistributeBalance(opts *bind.TransactOpts) (common.Hash, error)
+}
+type distributeAndFinaliser interface {
+	EstimateDistributeBalanceAndFinaliseGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error)
+	DistributeBalanceAndFinalise(opts *bind.TransactOpts) (common.Hash, error)
+}
+type promoter interface {
+	EstimatePromoteGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error)
+	Promote(opts *bind.TransactOpts) (common.Hash, error)
+}
+type bondReducer interface {
+	EstimateReduceBondAmountGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error)
+	ReduceBondAmount(opts *bind.TransactOpts) (common.Hash, error)
+}
+type vacancyGetter interface {
+	GetVacant(opts *bind.CallOpts) (bool, error)
+	GetPreMigrationBalance(opts *bind.CallOpts) (*big.Int, error)
+}
+type userDistributedGetter interface {
+	GetUserDistributed(opts *bind.CallOpts) (bool, error)
+}
+
+// A minipool binding with the same method signatures for every delegate version
+type MinipoolAdapter struct {
+	Minipool
+}
+
+// Wrap a minipool binding in an adapter
+func NewMinipoolAdapter(mp Minipool) *MinipoolAdapter {
+	return &MinipoolAdapter{
+		Minipool: mp,
+	}
+}
+
+// Create a minipool binding and wrap it in an adapter
+func NewMinipoolAdapterFromAddress(rp *rocketpool.RocketPool, address common.Address, opts *bind.CallOpts) (*MinipoolAdapter, error) {
+	mp, err := NewMinipool(rp, address, opts)
+	if err != nil {
+		return nil, err
+	}
+	return NewMinipoolAdapter(mp), nil
+}
+
+// Check if the minipool supports distributing skimmed rewards without exiting
+func (mp *MinipoolAdapter) SupportsRewardsOnlyDistribute() bool {
+	_, ok := mp.Minipool.(rewardsOnlyDistributor)
+	return ok
+}
+
+// Check if the minipool can distribute its balance and finalise in a single transaction
+func (mp *MinipoolAdapter) SupportsDistributeAndFinalise() bool {
+	_, ok := mp.Minipool.(distributeAndFinaliser)
+	return ok
+}
+
+// Check if the minipool supports promotion of a vacant (solo migration) minipool
+func (mp *MinipoolAdapter) SupportsPromote() bool {
+	_, ok := mp.Minipool.(promoter)
+	return ok
+}
+
+// Check if the minipool supports bond reduction
+func (mp *MinipoolAdapter) SupportsBondReduction() bool {
+	_, ok := mp.Minipool.(bondReducer)
+	return ok
+}
+
+// Estimate the gas of DistributeBalance
+func (mp *MinipoolAdapter) EstimateDistributeBalanceGas(rewardsOnly bool, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	switch typedMp := mp.Minipool.(type) {
+	case rewardsOnlyDistributor:
+		return typedMp.EstimateDistributeBalanceGas(rewardsOnly, opts)
+	case legacyDistributor:
+		if rewardsOnly {
+			return rocketpool.GasInfo{}, mp.unsupported("rewards-only distribution")
+		}
+		return typedMp.EstimateDistributeBalanceGas(opts)
+	}
+	return rocketpool.GasInfo{}, mp.unsupported("balance distribution")
+}
+
+// Distribute the minipool's ETH balance to the node operator and rETH staking pool.
+// Minipools that don't support rewards-only distribution return an error if rewardsOnly is set.
+func (mp *MinipoolAdapter) DistributeBalance(rewardsOnly bool, opts *bind.TransactOpts) (common.Hash, error) {
+	switch typedMp := mp.Minipool.(type) {
+	case rewardsOnlyDistributor:
+		return typedMp.DistributeBalance(rewardsOnly, opts)
+	case legacyDistributor:
+		if rewardsOnly {
+			return common.Hash{}, mp.unsupported("rewards-only distribution")
+		}
+		return typedMp.DistributeBalance(opts)
+	}
+	return common.Hash{}, mp.unsupported("balance distribution")
+}
+
+// Estimate the gas of DistributeBalanceAndFinalise
+func (mp *MinipoolAdapter) EstimateDistributeBalanceAndFinaliseGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	typedMp, ok := mp.Minipool.(distributeAndFinaliser)
+	if !ok {
+		return rocketpool.GasInfo{}, mp.unsupported("distributing and finalising")
+	}
+	return typedMp.EstimateDistributeBalanceAndFinaliseGas(opts)
+}
+
+// Distribute the minipool's ETH balance and finalise it in a single transaction
+func (mp *MinipoolAdapter) DistributeBalanceAndFinalise(opts *bind.TransactOpts) (common.Hash, error) {
+	typedMp, ok := mp.Minipool.(distributeAndFinaliser)
+	if !ok {
+		return common.Hash{}, mp.unsupported("distributing and finalising")
+	}
+	return typedMp.DistributeBalanceAndFinalise(opts)
+}
+
+// Estimate the gas of Promote
+func (mp *MinipoolAdapter) EstimatePromoteGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	typedMp, ok := mp.Minipool.(promoter)
+	if !ok {
+		return rocketpool.GasInfo{}, mp.unsupported("promotion")
+	}
+	return typedMp.EstimatePromoteGas(opts)
+}
+
+// Promote a vacant minipool
+func (mp *MinipoolAdapter) Promote(opts *bind.TransactOpts) (common.Hash, error) {
+	typedMp, ok := mp.Minipool.(promoter)
+	if !ok {
+		return common.Hash{}, mp.unsupported("promotion")
+	}
+	return typedMp.Promote(opts)
+}
+
+// Estimate the gas of ReduceBondAmount
+func (mp *MinipoolAdapter) EstimateReduceBondAmountGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	typedMp, ok := mp.Minipool.(bondReducer)
+	if !ok {
+		return rocketpool.GasInfo{}, mp.unsupported("bond reduction")
+	}
+	return typedMp.EstimateReduceBondAmountGas(opts)
+}
+
+// Reduce the minipool's bond once its bond reduction window is open
+func (mp *MinipoolAdapter) ReduceBondAmount(opts *bind.TransactOpts) (common.Hash, error) {
+	typedMp, ok := mp.Minipool.(bondReducer)
+	if !ok {
+		return common.Hash{}, mp.unsupported("bond reduction")
+	}
+	return typedMp.ReduceBondAmount(opts)
+}
+
+// Check if the minipool is vacant; minipools that predate solo migration are never vacant
+func (mp *MinipoolAdapter) GetVacant(opts *bind.CallOpts) (bool, error) {
+	typedMp, ok := mp.Minipool.(vacancyGetter)
+	if !ok {
+		return false, nil
+	}
+	return typedMp.GetVacant(opts)
+}
+
+// Get the balance of a vacant minipool before it was migrated; minipools that predate solo migration have none
+func (mp *MinipoolAdapter) GetPreMigrationBalance(opts *bind.CallOpts) (*big.Int, error) {
+	typedMp, ok := mp.Minipool.(vacancyGetter)
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	return typedMp.GetPreMigrationBalance(opts)
+}
+
+// Check if the minipool's balance has been distributed by a user; minipools that predate user distribution never are
+func (mp *MinipoolAdapter) GetUserDistributed(opts *bind.CallOpts) (bool, error) {
+	typedMp, ok := mp.Minipool.(userDistributedGetter)
+	if !ok {
+		return false, nil
+	}
+	return typedMp.GetUserDistributed(opts)
+}
+
+// Create the error for an unsupported function
+func (mp *MinipoolAdapter) unsupported(function string) error {
+	return fmt.Errorf("%s is %w (minipool %s is v%d)", function, ErrUnsupportedByMinipoolVersion, mp.GetAddress().Hex(), mp.GetVersion())
+}
+
+// Make sure the bindings keep the capabilities the adapter routes to
+var (
+	_ legacyDistributor      = &minipool_v2{}
+	_ distributeAndFinaliser = &minipool_v2{}
+	_ rewardsOnlyDistributor = &minipool_v3{}
+	_ promoter               = &minipool_v3{}
+	_ bondReducer            = &minipool_v3{}
+	_ vacancyGetter          = &minipool_v3{}
+	_ userDistributedGetter  = &minipool_v3{}
+)
@@ -0,0 +1,95 @@
+package minipool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMinipoolAdapterSupports(t *testing.T) {
+	address := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	tests := []struct {
+		name                  string
+		minipool              Minipool
+		rewardsOnlyDistribute bool
+		distributeAndFinalise bool
+		promote               bool
+		bondReduction         bool
+	}{
+		{
+			name:                  "v2",
+			minipool:              &minipool_v2{Address: address, Version: 2},
+			distributeAndFinalise: true,
+		},
+		{
+			name:                  "v3",
+			minipool:              &minipool_v3{Address: address, Version: 3},
+			rewardsOnlyDistribute: true,
+			promote:               true,
+			bondReduction:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			adapter := NewMinipoolAdapter(test.minipool)
+			if actual := adapter.SupportsRewardsOnlyDistribute(); actual != test.rewardsOnlyDistribute {
+				t.Errorf("expected rewards-only distribution support %t but got %t", test.rewardsOnlyDistribute, actual)
+			}
+			if actual := adapter.SupportsDistributeAndFinalise(); actual != test.distributeAndFinalise {
+				t.Errorf("expected distribute and finalise support %t but got %t", test.distributeAndFinalise, actual)
+			}
+			if actual := adapter.SupportsPromote(); actual != test.promote {
+				t.Errorf("expected promotion support %t but got %t", test.promote, actual)
+			}
+			if actual := adapter.SupportsBondReduction(); actual != test.bondReduction {
+				t.Errorf("expected bond reduction support %t but got %t", test.bondReduction, actual)
+			}
+
+			// Unsupported functions fail without calling the minipool
+			opts := &bind.TransactOpts{}
+			checkUnsupported := func(function string, err error) {
+				if !errors.Is(err, ErrUnsupportedByMinipoolVersion) {
+					t.Errorf("expected %s to be unsupported but got %v", function, err)
+				}
+			}
+			if !test.rewardsOnlyDistribute {
+				_, err := adapter.DistributeBalance(true, opts)
+				checkUnsupported("rewards-only distribution", err)
+			}
+			if !test.distributeAndFinalise {
+				_, err := adapter.DistributeBalanceAndFinalise(opts)
+				checkUnsupported("distributing and finalising", err)
+			}
+			if !test.promote {
+				_, err := adapter.Promote(opts)
+				checkUnsupported("promotion", err)
+			}
+			if !test.bondReduction {
+				_, err := adapter.ReduceBondAmount(opts)
+				checkUnsupported("bond reduction", err)
+			}
+		})
+	}
+}
+
+func TestMinipoolAdapterLegacyDefaults(t *testing.T) {
+	adapter := NewMinipoolAdapter(&minipool_v2{Address: common.HexToAddress("0x2222222222222222222222222222222222222222"), Version: 2})
+	opts := &bind.CallOpts{}
+
+	vacant, err := adapter.GetVacant(opts)
+	if err != nil || vacant {
+		t.Errorf("expected a v2 minipool not to be vacant, got %t (%v)", vacant, err)
+	}
+	balance, err := adapter.GetPreMigrationBalance(opts)
+	if err != nil || balance.Sign() != 0 {
+		t.Errorf("expected a v2 minipool to have no pre-migration balance, got %v (%v)", balance, err)
+	}
+	distributed, err := adapter.GetUserDistributed(opts)
+	if err != nil || distributed {
+		t.Errorf("expected a v2 minipool not to be user distributed, got %t (%v)", distributed, err)
+	}
+}
@@ -564,6 +564,19 @@ func GetMinipoolPenaltyCount(rp *rocketpool.RocketPool, minipoolAddress common.A
 	return penalties.Uint64(), nil
 }
 
+// Get the penalty rate applied to a minipool's node share, as a fraction of 1 ETH; this is capped at the network's maximum penalty rate
+func GetMinipoolPenaltyRate(rp *rocketpool.RocketPool, minipoolAddress common.Address, opts *bind.CallOpts) (*big.Int, error) {
+	rocketMinipoolPenalty, err := getRocketMinipoolPenalty(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+	penaltyRate := new(*big.Int)
+	if err := rocketMinipoolPenalty.Call(opts, penaltyRate, "getPenaltyRate", minipoolAddress); err != nil {
+		return nil, fmt.Errorf("error getting minipool penalty rate: %w", err)
+	}
+	return *penaltyRate, nil
+}
+
 // Get the vacant minipool count
 func GetVacantMinipoolCount(rp *rocketpool.RocketPool, opts *bind.CallOpts) (uint64, error) {
 	rocketMinipoolManager, err := getRocketMinipoolManager(rp, opts)
@@ -624,3 +637,11 @@ func getRocketMinipoolManager(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*
 	defer rocketMinipoolManagerLock.Unlock()
 	return rp.GetContract("rocketMinipoolManager", opts)
 }
+
+var rocketMinipoolPenaltyLock sync.Mutex
+
+func getRocketMinipoolPenalty(rp *rocketpool.RocketPool, opts *bind.CallOpts) (*rocketpool.Contract, error) {
+	rocketMinipoolPenaltyLock.Lock()
+	defer rocketMinipoolPenaltyLock.Unlock()
+	return rp.GetContract("rocketMinipoolPenalty", opts)
+}
@@ -24,7 +24,11 @@ const (
 	NodeAddressBatchSize               = 50
 	NodeDetailsBatchSize               = 20
 	SmoothingPoolCountBatchSize uint64 = 2000
+	TimezoneCountBatchSize      uint64 = 2000
 	NativeNodeDetailsBatchSize         = 10000
+	nodeDetailsFastBatchSize    int    = 200
+	nodeDetailsFastThreadLimit  int    = 6
+	timezoneCountThreadLimit    int    = 6
 )
 
 // Node details
@@ -37,6 +41,15 @@ type NodeDetails struct {
 	RPLWithdrawalAddress            common.Address `json:"rplWithdrawalAddress"`
 	PendingRPLWithdrawalAddress     common.Address `json:"pendingRPLWithdrawalAddress"`
 	TimezoneLocation                string         `json:"timezoneLocation"`
+	SmoothingPoolRegistrationState  bool           `json:"smoothingPoolRegistrationState"`
+	RplStake                        *big.Int       `json:"rplStake"`
+}
+
+// Filters for GetNodes; unset fields don't filter
+type NodeFilter struct {
+	SmoothingPoolRegistrationState *bool    `json:"smoothingPoolRegistrationState"`
+	MinimumRplStake                *big.Int `json:"minimumRplStake"`
+	TimezoneLocations              []string `json:"timezoneLocations"` // Nodes in any of these timezones
 }
 
 // Count of nodes belonging to a timezone
@@ -65,51 +78,155 @@ func GetNodeManagerVersion(rp *rocketpool.RocketPool, opts *bind.CallOpts) (uint
 	return rocketpool.GetContractVersion(rp, *rocketNodeManager.Address, opts)
 }
 
-// Get all node details
-// The 'includeRplWithdrawalAddress' flag is used for backwards compatibility with Atlas, - set it to `false` if Houston hasn't been deployed yet
-func GetNodes(rp *rocketpool.RocketPool, includeRplWithdrawalAddress bool, opts *bind.CallOpts) ([]NodeDetails, error) {
-
-	// Get node addresses
-	nodeAddresses, err := GetNodeAddresses(rp, opts)
+// Check if the Node Manager is from Houston or later, which added RPL withdrawal addresses
+func isHoustonNodeManager(rp *rocketpool.RocketPool, opts *bind.CallOpts) (bool, error) {
+	version, err := GetNodeManagerVersion(rp, opts)
 	if err != nil {
-		return []NodeDetails{}, err
+		return false, fmt.Errorf("error getting node manager version: %w", err)
 	}
+	return version > 3, nil
+}
 
-	// Load node details in batches
-	details := make([]NodeDetails, len(nodeAddresses))
-	for bsi := 0; bsi < len(nodeAddresses); bsi += NodeDetailsBatchSize {
+// Get the details of the nodes that pass the filter, using multicall.
+// Nodes are checked in index order from offset until limit of them pass; a limit of 0 returns every node that passes.
+// The returned offset is where the next page starts, which is the node count once every node has been checked.
+func GetNodes(rp *rocketpool.RocketPool, multicallAddress common.Address, filter NodeFilter, offset uint64, limit uint64, opts *bind.CallOpts) ([]NodeDetails, uint64, error) {
+	rocketNodeManager, err := getRocketNodeManager(rp, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	rocketNodeStaking, err := getRocketNodeStaking(rp, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	includeRplWithdrawalAddress, err := isHoustonNodeManager(rp, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodeCount, err := GetNodeCount(rp, opts)
+	if err != nil {
+		return nil, 0, err
+	}
 
-		// Get batch start & end index
-		nsi := bsi
-		nei := bsi + NodeDetailsBatchSize
-		if nei > len(nodeAddresses) {
-			nei = len(nodeAddresses)
+	// Load the nodes a chunk at a time until the page is full
+	chunkSize := uint64(nodeDetailsFastBatchSize * nodeDetailsFastThreadLimit)
+	nodes := []NodeDetails{}
+	for offset < nodeCount {
+		count := nodeCount - offset
+		if count > chunkSize {
+			count = chunkSize
 		}
-
-		// Load details
-		var wg errgroup.Group
-		for ni := nsi; ni < nei; ni++ {
-			ni := ni
-			wg.Go(func() error {
-				nodeAddress := nodeAddresses[ni]
-				nodeDetails, err := GetNodeDetails(rp, nodeAddress, includeRplWithdrawalAddress, opts)
-				if err == nil {
-					details[ni] = nodeDetails
-				}
-				return err
-			})
+		details, err := getNodeDetailsFast(rp, multicallAddress, rocketNodeManager, rocketNodeStaking, includeRplWithdrawalAddress, offset, count, opts)
+		if err != nil {
+			return nil, 0, err
 		}
-		if err := wg.Wait(); err != nil {
-			return []NodeDetails{}, err
+		for i, nodeDetails := range details {
+			nodeDetails.TimezoneLocation = strings.Sanitize(nodeDetails.TimezoneLocation)
+			if !filter.matches(nodeDetails) {
+				continue
+			}
+			nodes = append(nodes, nodeDetails)
+			if limit > 0 && uint64(len(nodes)) == limit {
+				return nodes, offset + uint64(i) + 1, nil
+			}
 		}
+		offset += count
+	}
+	return nodes, nodeCount, nil
+
+}
+
+// Get the details of count nodes starting at the given index, using multicall
+func getNodeDetailsFast(rp *rocketpool.RocketPool, multicallAddress common.Address, rocketNodeManager *rocketpool.Contract, rocketNodeStaking *rocketpool.Contract, includeRplWithdrawalAddress bool, offset uint64, count uint64, opts *bind.CallOpts) ([]NodeDetails, error) {
+	details := make([]NodeDetails, count)
 
+	// Load the addresses, then the details
+	var wg errgroup.Group
+	wg.SetLimit(nodeDetailsFastThreadLimit)
+	for i := 0; i < int(count); i += nodeAddressFastBatchSize {
+		i := i
+		max := i + nodeAddressFastBatchSize
+		if max > int(count) {
+			max = int(count)
+		}
+		wg.Go(func() error {
+			mc, err := multicall.NewMultiCaller(rp.Client, multicallAddress)
+			if err != nil {
+				return err
+			}
+			for j := i; j < max; j++ {
+				mc.AddCall(rocketNodeManager, &details[j].Address, "getNodeAt", big.NewInt(int64(offset)+int64(j)))
+			}
+			_, err = mc.FlexibleCall(true, opts)
+			if err != nil {
+				return fmt.Errorf("error executing multicall: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting node addresses: %w", err)
 	}
 
-	// Return
+	for i := 0; i < int(count); i += nodeDetailsFastBatchSize {
+		i := i
+		max := i + nodeDetailsFastBatchSize
+		if max > int(count) {
+			max = int(count)
+		}
+		wg.Go(func() error {
+			mc, err := multicall.NewMultiCaller(rp.Client, multicallAddress)
+			if err != nil {
+				return err
+			}
+			for j := i; j < max; j++ {
+				nodeDetails := &details[j]
+				address := nodeDetails.Address
+				mc.AddCall(rocketNodeManager, &nodeDetails.Exists, "getNodeExists", address)
+				mc.AddCall(rp.RocketStorageContract, &nodeDetails.PrimaryWithdrawalAddress, "getNodeWithdrawalAddress", address)
+				mc.AddCall(rp.RocketStorageContract, &nodeDetails.PendingPrimaryWithdrawalAddress, "getNodePendingWithdrawalAddress", address)
+				mc.AddCall(rocketNodeManager, &nodeDetails.TimezoneLocation, "getNodeTimezoneLocation", address)
+				mc.AddCall(rocketNodeManager, &nodeDetails.SmoothingPoolRegistrationState, "getSmoothingPoolRegistrationState", address)
+				mc.AddCall(rocketNodeStaking, &nodeDetails.RplStake, "getNodeRPLStake", address)
+				if includeRplWithdrawalAddress {
+					mc.AddCall(rocketNodeManager, &nodeDetails.IsRPLWithdrawalAddressSet, "getNodeRPLWithdrawalAddressIsSet", address)
+					mc.AddCall(rocketNodeManager, &nodeDetails.RPLWithdrawalAddress, "getNodeRPLWithdrawalAddress", address)
+					mc.AddCall(rocketNodeManager, &nodeDetails.PendingRPLWithdrawalAddress, "getNodePendingRPLWithdrawalAddress", address)
+				}
+			}
+			_, err = mc.FlexibleCall(true, opts)
+			if err != nil {
+				return fmt.Errorf("error executing multicall: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting node details: %w", err)
+	}
 	return details, nil
 
 }
 
+// Check if a node passes the filter
+func (f NodeFilter) matches(details NodeDetails) bool {
+	if f.SmoothingPoolRegistrationState != nil && details.SmoothingPoolRegistrationState != *f.SmoothingPoolRegistrationState {
+		return false
+	}
+	if f.MinimumRplStake != nil && (details.RplStake == nil || details.RplStake.Cmp(f.MinimumRplStake) < 0) {
+		return false
+	}
+	if len(f.TimezoneLocations) == 0 {
+		return true
+	}
+	for _, timezoneLocation := range f.TimezoneLocations {
+		if details.TimezoneLocation == timezoneLocation {
+			return true
+		}
+	}
+	return false
+}
+
 // Get all node addresses
 func GetNodeAddresses(rp *rocketpool.RocketPool, opts *bind.CallOpts) ([]common.Address, error) {
 
@@ -216,6 +333,8 @@ func GetNodeDetails(rp *rocketpool.RocketPool, nodeAddress common.Address, inclu
 	var rplWithdrawalAddress common.Address
 	var pendingRPLWithdrawalAddress common.Address
 	var timezoneLocation string
+	var smoothingPoolRegistrationState bool
+	var rplStake *big.Int
 
 	// Load data
 	wg.Go(func() error {
@@ -255,6 +374,16 @@ func GetNodeDetails(rp *rocketpool.RocketPool, nodeAddress common.Address, inclu
 		timezoneLocation, err = GetNodeTimezoneLocation(rp, nodeAddress, opts)
 		return err
 	})
+	wg.Go(func() error {
+		var err error
+		smoothingPoolRegistrationState, err = GetSmoothingPoolRegistrationState(rp, nodeAddress, opts)
+		return err
+	})
+	wg.Go(func() error {
+		var err error
+		rplStake, err = GetNodeRPLStake(rp, nodeAddress, opts)
+		return err
+	})
 
 	// Wait for data
 	if err := wg.Wait(); err != nil {
@@ -271,6 +400,8 @@ func GetNodeDetails(rp *rocketpool.RocketPool, nodeAddress common.Address, inclu
 		RPLWithdrawalAddress:            rplWithdrawalAddress,
 		PendingRPLWithdrawalAddress:     pendingRPLWithdrawalAddress,
 		TimezoneLocation:                timezoneLocation,
+		SmoothingPoolRegistrationState:  smoothingPoolRegistrationState,
+		RplStake:                        rplStake,
 	}, nil
 
 }
@@ -346,7 +477,7 @@ func EstimateRegisterNodeGas(rp *rocketpool.RocketPool, timezoneLocation string,
 	if err != nil {
 		return rocketpool.GasInfo{}, err
 	}
-	_, err = time.LoadLocation(timezoneLocation)
+	err = ValidateTimezoneLocation(timezoneLocation)
 	if err != nil {
 		return rocketpool.GasInfo{}, fmt.Errorf("error verifying timezone [%s]: %w", timezoneLocation, err)
 	}
@@ -359,7 +490,7 @@ func RegisterNode(rp *rocketpool.RocketPool, timezoneLocation string, opts *bind
 	if err != nil {
 		return common.Hash{}, err
 	}
-	_, err = time.LoadLocation(timezoneLocation)
+	err = ValidateTimezoneLocation(timezoneLocation)
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("error verifying timezone [%s]: %w", timezoneLocation, err)
 	}
@@ -376,7 +507,7 @@ func EstimateSetTimezoneLocationGas(rp *rocketpool.RocketPool, timezoneLocation
 	if err != nil {
 		return rocketpool.GasInfo{}, err
 	}
-	_, err = time.LoadLocation(timezoneLocation)
+	err = ValidateTimezoneLocation(timezoneLocation)
 	if err != nil {
 		return rocketpool.GasInfo{}, fmt.Errorf("error verifying timezone [%s]: %w", timezoneLocation, err)
 	}
@@ -389,7 +520,7 @@ func SetTimezoneLocation(rp *rocketpool.RocketPool, timezoneLocation string, opt
 	if err != nil {
 		return common.Hash{}, err
 	}
-	_, err = time.LoadLocation(timezoneLocation)
+	err = ValidateTimezoneLocation(timezoneLocation)
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("error verifying timezone [%s]: %w", timezoneLocation, err)
 	}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestNodeFilterMatches(t *testing.T) {
+	registered := true
+	notRegistered := false
+	details := NodeDetails{
+		TimezoneLocation:               "Europe/Berlin",
+		SmoothingPoolRegistrationState: true,
+		RplStake:                       big.NewInt(1000),
+	}
+
+	tests := []struct {
+		name     string
+		filter   NodeFilter
+		details  NodeDetails
+		expected bool
+	}{
+		{name: "no filter", filter: NodeFilter{}, details: details, expected: true},
+		{name: "smoothing pool registered", filter: NodeFilter{SmoothingPoolRegistrationState: &registered}, details: details, expected: true},
+		{name: "smoothing pool not registered", filter: NodeFilter{SmoothingPoolRegistrationState: &notRegistered}, details: details, expected: false},
+		{name: "RPL stake above the minimum", filter: NodeFilter{MinimumRplStake: big.NewInt(999)}, details: details, expected: true},
+		{name: "RPL stake at the minimum", filter: NodeFilter{MinimumRplStake: big.NewInt(1000)}, details: details, expected: true},
+		{name: "RPL stake below the minimum", filter: NodeFilter{MinimumRplStake: big.NewInt(1001)}, details: details, expected: false},
+		{name: "RPL stake not loaded", filter: NodeFilter{MinimumRplStake: big.NewInt(0)}, details: NodeDetails{}, expected: false},
+		{name: "one of the timezones", filter: NodeFilter{TimezoneLocations: []string{"America/New_York", "Europe/Berlin"}}, details: details, expected: true},
+		{name: "none of the timezones", filter: NodeFilter{TimezoneLocations: []string{"America/New_York"}}, details: details, expected: false},
+		{
+			name: "every filter",
+			filter: NodeFilter{
+				SmoothingPoolRegistrationState: &registered,
+				MinimumRplStake:                big.NewInt(500),
+				TimezoneLocations:              []string{"Europe/Berlin"},
+			},
+			details:  details,
+			expected: true,
+		},
+		{
+			name: "every filter but one",
+			filter: NodeFilter{
+				SmoothingPoolRegistrationState: &registered,
+				MinimumRplStake:                big.NewInt(500),
+				TimezoneLocations:              []string{"Europe/London"},
+			},
+			details:  details,
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if matches := test.filter.matches(test.details); matches != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+package operator
+
+import (
+	"github.com/hashicorp/go-version"
+)
+
+// Network versions
+var houstonVersion, _ = version.NewSemver("1.3.0")
@@ -0,0 +1,369 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+	"golang.org/x/sync/errgroup"
+)
+
+// Settings
+const creditThreadLimit int = 6
+
+// A change to a node's deposit credit or ETH balance
+type CreditLedgerEntryType string
+
+const (
+	CreditLedgerEntry_BondReduction CreditLedgerEntryType = "bondReduction" // Credit from reducing a minipool's bond
+	CreditLedgerEntry_SoloMigration CreditLedgerEntryType = "soloMigration" // Credit from promoting a migrated solo validator
+	CreditLedgerEntry_Deposit       CreditLedgerEntryType = "deposit"       // Credit and ETH balance used to create a minipool
+	CreditLedgerEntry_EthDeposited  CreditLedgerEntryType = "ethDeposited"  // ETH added to the node's balance in the deposit contract
+	CreditLedgerEntry_EthWithdrawn  CreditLedgerEntryType = "ethWithdrawn"  // ETH withdrawn from the node's balance in the deposit contract
+)
+
+// An entry in a node's credit ledger
+type CreditLedgerEntry struct {
+	Type            CreditLedgerEntryType `json:"type"`
+	Time            time.Time             `json:"time"`
+	BlockNumber     uint64                `json:"blockNumber"`
+	TxHash          common.Hash           `json:"txHash"`
+	MinipoolAddress common.Address        `json:"minipoolAddress"` // The minipool the entry is for, if any
+	Counterparty    common.Address        `json:"counterparty"`    // Who deposited the ETH, or where it was withdrawn to
+
+	// The changes, which are negative when something is used or withdrawn
+	CreditChange     *big.Int `json:"creditChange"`
+	EthBalanceChange *big.Int `json:"ethBalanceChange"`
+
+	// The running balances after the entry
+	CreditBalance *big.Int `json:"creditBalance"`
+	EthBalance    *big.Int `json:"ethBalance"`
+
+	logIndex uint
+}
+
+// A node's deposit credit and ETH balance history, rebuilt from events
+type CreditLedger struct {
+	NodeAddress common.Address      `json:"nodeAddress"`
+	Entries     []CreditLedgerEntry `json:"entries"`
+
+	// The balances at the end of the ledger, and the balances the contracts report
+	CreditBalance        *big.Int `json:"creditBalance"`
+	EthBalance           *big.Int `json:"ethBalance"`
+	OnChainCreditBalance *big.Int `json:"onChainCreditBalance"`
+	OnChainEthBalance    *big.Int `json:"onChainEthBalance"`
+}
+
+// The events of one of the node's minipools that affect its credit
+type creditMinipoolEvents struct {
+	bond           *big.Int
+	vacancyBalance *big.Int // The balance of a migrated solo validator; nil if the minipool isn't vacant
+	logs           []types.Log
+}
+
+// Get a node's deposit credit and ETH balance ledger from the events of the node deposit contract and the node's minipools
+func GetCreditLedger(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddress common.Address, intervalSize *big.Int) (*CreditLedger, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	ledger := &CreditLedger{
+		NodeAddress:       nodeAddress,
+		Entries:           []CreditLedgerEntry{},
+		CreditBalance:     big.NewInt(0),
+		EthBalance:        big.NewInt(0),
+		OnChainEthBalance: big.NewInt(0),
+	}
+	nodeTopic := common.BytesToHash(nodeAddress.Bytes())
+
+	// Get the node's minipools and the transactions that created them
+	created, exists := contracts.RocketMinipoolManager.ABI.Events["MinipoolCreated"]
+	if !exists {
+		return nil, fmt.Errorf("rocketMinipoolManager does not have a MinipoolCreated event")
+	}
+	query := eth.FilterQuery{
+		ToBlock: contracts.ElBlockNumber,
+		Topics:  [][]common.Hash{{created.ID}, nil, {nodeTopic}},
+	}
+	createdLogs, err := eth.FilterContractLogs(rp, "rocketMinipoolManager", query, intervalSize, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool creation events for node %s: %w", nodeAddress.Hex(), err)
+	}
+	minipools := map[common.Address]*creditMinipoolEvents{}
+	minipoolsByTx := map[common.Hash]common.Address{}
+	minipoolAddresses := make([]common.Address, len(createdLogs))
+	for i, log := range createdLogs {
+		address := common.BytesToAddress(log.Topics[1].Bytes())
+		minipools[address] = &creditMinipoolEvents{}
+		minipoolsByTx[log.TxHash] = address
+		minipoolAddresses[i] = address
+	}
+
+	// Get the minipool events and bonds
+	if len(minipoolAddresses) > 0 {
+		mp, err := minipool.NewMinipoolFromVersion(rp, common.Address{}, 3, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating minipool binding: %w", err)
+		}
+		minipoolAbi := mp.GetContract().ABI
+		topics := []common.Hash{minipoolAbi.Events["BondReduced"].ID, minipoolAbi.Events["MinipoolVacancyPrepared"].ID, minipoolAbi.Events["MinipoolPromoted"].ID}
+		logs, err := eth.GetLogs(rp, minipoolAddresses, [][]common.Hash{topics}, intervalSize, nil, contracts.ElBlockNumber, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting minipool events for node %s: %w", nodeAddress.Hex(), err)
+		}
+
+		// Destroyed minipools have no code to call; they're from before credit existed, so they can be skipped
+		hasCode := make([]bool, len(minipoolAddresses))
+		var wg errgroup.Group
+		wg.SetLimit(creditThreadLimit)
+		for i, address := range minipoolAddresses {
+			i, address := i, address
+			wg.Go(func() error {
+				code, err := rp.Client.CodeAt(context.Background(), address, contracts.ElBlockNumber)
+				if err != nil {
+					return fmt.Errorf("error getting code for minipool %s: %w", address.Hex(), err)
+				}
+				hasCode[i] = len(code) > 0
+				return nil
+			})
+		}
+		if err := wg.Wait(); err != nil {
+			return nil, err
+		}
+
+		mc := contracts.Multicaller
+		for i, address := range minipoolAddresses {
+			if !hasCode[i] {
+				continue
+			}
+			mp, err := minipool.NewMinipoolFromVersion(rp, address, 3, nil)
+			if err != nil {
+				return nil, fmt.Errorf("error creating binding for minipool %s: %w", address.Hex(), err)
+			}
+			mc.AddCall(mp.GetContract(), &minipools[address].bond, "getNodeDepositBalance")
+		}
+		_, err = mc.FlexibleCall(true, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error executing multicall: %w", err)
+		}
+
+		for _, log := range logs {
+			events := minipools[log.Address]
+			if events == nil {
+				continue
+			}
+			events.logs = append(events.logs, log)
+		}
+		for address, events := range minipools {
+			entries, err := getMinipoolCreditEntries(minipoolAbi, address, events)
+			if err != nil {
+				return nil, err
+			}
+			ledger.Entries = append(ledger.Entries, entries...)
+		}
+	}
+
+	// Get the node deposit contract events
+	depositAbi := contracts.RocketNodeDeposit.ABI
+	eventIDs := []common.Hash{}
+	for _, name := range []string{"DepositReceived", "DepositFor", "Withdrawal"} {
+		if event, exists := depositAbi.Events[name]; exists {
+			eventIDs = append(eventIDs, event.ID)
+		}
+	}
+	query = eth.FilterQuery{
+		ToBlock: contracts.ElBlockNumber,
+		Topics:  [][]common.Hash{eventIDs, {nodeTopic}},
+	}
+	depositLogs, err := eth.FilterContractLogs(rp, "rocketNodeDeposit", query, intervalSize, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node deposit events for node %s: %w", nodeAddress.Hex(), err)
+	}
+	for _, log := range depositLogs {
+		event, err := depositAbi.EventByID(log.Topics[0])
+		if err != nil {
+			return nil, fmt.Errorf("error decoding node deposit event in tx %s: %w", log.TxHash.Hex(), err)
+		}
+		values, err := unpackCreditEvent(depositAbi, event.Name, log)
+		if err != nil {
+			return nil, err
+		}
+		entry := newCreditLedgerEntry(log, values[len(values)-1])
+
+		switch event.Name {
+		case "DepositReceived":
+			// Deposits that weren't paid in full used credit and ETH balance
+			address, exists := minipoolsByTx[log.TxHash]
+			if !exists {
+				continue
+			}
+			events := minipools[address]
+			if events.vacancyBalance != nil || events.bond == nil {
+				continue
+			}
+			shortfall := big.NewInt(0).Sub(events.bond, values[0])
+			if shortfall.Sign() <= 0 {
+				continue
+			}
+			entry.Type = CreditLedgerEntry_Deposit
+			entry.MinipoolAddress = address
+			entry.CreditChange = shortfall // Split between credit and ETH balance once the running credit is known
+
+		case "DepositFor":
+			entry.Type = CreditLedgerEntry_EthDeposited
+			entry.Counterparty = common.BytesToAddress(log.Topics[2].Bytes())
+			entry.EthBalanceChange = values[0]
+
+		case "Withdrawal":
+			entry.Type = CreditLedgerEntry_EthWithdrawn
+			entry.Counterparty = common.BytesToAddress(log.Topics[2].Bytes())
+			entry.EthBalanceChange = big.NewInt(0).Neg(values[0])
+		}
+		ledger.Entries = append(ledger.Entries, entry)
+	}
+
+	ledger.runBalances()
+
+	// Get the balances the contracts report
+	mc := contracts.Multicaller
+	mc.AddCall(contracts.RocketNodeDeposit, &ledger.OnChainCreditBalance, "getNodeDepositCredit", nodeAddress)
+	if _, exists := depositAbi.Methods["getNodeEthBalance"]; exists {
+		mc.AddCall(contracts.RocketNodeDeposit, &ledger.OnChainEthBalance, "getNodeEthBalance", nodeAddress)
+	}
+	_, err = mc.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	return ledger, nil
+}
+
+// Check if the ledger's balances match the balances the contracts report
+func (l *CreditLedger) IsReconciled() bool {
+	return l.CreditBalance.Cmp(l.OnChainCreditBalance) == 0 && l.EthBalance.Cmp(l.OnChainEthBalance) == 0
+}
+
+// Get the entries in the given time range, including the start and excluding the end
+func (l *CreditLedger) GetEntriesBetween(start time.Time, end time.Time) []CreditLedgerEntry {
+	entries := []CreditLedgerEntry{}
+	for _, entry := range l.Entries {
+		if !entry.Time.Before(start) && entry.Time.Before(end) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Sort the entries and run the balances in order; deposits use credit before ETH balance.
+// Deposit entries come in with the full shortfall as their credit change, and leave with it split between credit and ETH balance.
+func (l *CreditLedger) runBalances() {
+	sort.Slice(l.Entries, func(i, j int) bool {
+		if l.Entries[i].BlockNumber != l.Entries[j].BlockNumber {
+			return l.Entries[i].BlockNumber < l.Entries[j].BlockNumber
+		}
+		return l.Entries[i].logIndex < l.Entries[j].logIndex
+	})
+	for i := range l.Entries {
+		entry := &l.Entries[i]
+		if entry.Type == CreditLedgerEntry_Deposit {
+			shortfall := entry.CreditChange
+			creditUsed := big.NewInt(0).Set(shortfall)
+			if creditUsed.Cmp(l.CreditBalance) > 0 {
+				creditUsed.Set(l.CreditBalance)
+			}
+			entry.CreditChange = creditUsed.Neg(creditUsed)
+			entry.EthBalanceChange = big.NewInt(0).Add(shortfall, entry.CreditChange)
+			entry.EthBalanceChange.Neg(entry.EthBalanceChange)
+		}
+		l.CreditBalance.Add(l.CreditBalance, entry.CreditChange)
+		l.EthBalance.Add(l.EthBalance, entry.EthBalanceChange)
+		entry.CreditBalance = big.NewInt(0).Set(l.CreditBalance)
+		entry.EthBalance = big.NewInt(0).Set(l.EthBalance)
+	}
+}
+
+// Get the ledger entries for a minipool's bond reductions and solo migration
+func getMinipoolCreditEntries(minipoolAbi *abi.ABI, address common.Address, events *creditMinipoolEvents) ([]CreditLedgerEntry, error) {
+	entries := []CreditLedgerEntry{}
+	reduced := false
+	for _, log := range events.logs {
+		event, err := minipoolAbi.EventByID(log.Topics[0])
+		if err != nil {
+			return nil, fmt.Errorf("error decoding minipool %s event in tx %s: %w", address.Hex(), log.TxHash.Hex(), err)
+		}
+		values, err := unpackCreditEvent(minipoolAbi, event.Name, log)
+		if err != nil {
+			return nil, err
+		}
+
+		switch event.Name {
+		case "BondReduced":
+			// The bond the minipool was created with is the one before its first reduction
+			if !reduced && events.vacancyBalance == nil {
+				events.bond = values[0]
+			}
+			reduced = true
+			entry := newCreditLedgerEntry(log, values[2])
+			entry.Type = CreditLedgerEntry_BondReduction
+			entry.MinipoolAddress = address
+			entry.CreditChange = big.NewInt(0).Sub(values[0], values[1])
+			entries = append(entries, entry)
+
+		case "MinipoolVacancyPrepared":
+			events.bond = values[0]
+			events.vacancyBalance = values[1]
+
+		case "MinipoolPromoted":
+			// The node is credited with the solo validator's balance above the new bond
+			if events.vacancyBalance == nil {
+				continue
+			}
+			entry := newCreditLedgerEntry(log, values[0])
+			entry.Type = CreditLedgerEntry_SoloMigration
+			entry.MinipoolAddress = address
+			entry.CreditChange = big.NewInt(0).Sub(events.vacancyBalance, events.bond)
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// Unpack the non-indexed values of an event, which must all be integers
+func unpackCreditEvent(contractAbi *abi.ABI, eventName string, log types.Log) ([]*big.Int, error) {
+	unpacked, err := contractAbi.Unpack(eventName, log.Data)
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking %s event in tx %s: %w", eventName, log.TxHash.Hex(), err)
+	}
+	values := make([]*big.Int, len(unpacked))
+	for i, value := range unpacked {
+		number, ok := value.(*big.Int)
+		if !ok {
+			return nil, fmt.Errorf("unexpected value type %T in %s event in tx %s", value, eventName, log.TxHash.Hex())
+		}
+		values[i] = number
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s event in tx %s has no values", eventName, log.TxHash.Hex())
+	}
+	return values, nil
+}
+
+// Create a ledger entry for an event with no balance changes yet
+func newCreditLedgerEntry(log types.Log, eventTime *big.Int) CreditLedgerEntry {
+	return CreditLedgerEntry{
+		Time:             time.Unix(eventTime.Int64(), 0),
+		BlockNumber:      log.BlockNumber,
+		TxHash:           log.TxHash,
+		CreditChange:     big.NewInt(0),
+		EthBalanceChange: big.NewInt(0),
+		logIndex:         log.Index,
+	}
+}
@@ -0,0 +1,126 @@
+package operator
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestCreditLedgerRunBalances(t *testing.T) {
+	// The changes are in whole numbers to keep the table readable; deposits carry their full shortfall as the credit change
+	type entry struct {
+		entryType    CreditLedgerEntryType
+		block        uint64
+		logIndex     uint
+		creditChange int64
+		ethChange    int64
+	}
+	type balances struct {
+		entryType    CreditLedgerEntryType
+		creditChange int64
+		ethChange    int64
+		credit       int64
+		eth          int64
+	}
+	tests := []struct {
+		name     string
+		entries  []entry
+		expected []balances // In ledger order
+	}{
+		{
+			name:     "empty",
+			entries:  []entry{},
+			expected: []balances{},
+		},
+		{
+			name: "deposit covered by credit",
+			entries: []entry{
+				{CreditLedgerEntry_BondReduction, 1, 0, 8, 0},
+				{CreditLedgerEntry_Deposit, 2, 0, 8, 0},
+			},
+			expected: []balances{
+				{CreditLedgerEntry_BondReduction, 8, 0, 8, 0},
+				{CreditLedgerEntry_Deposit, -8, 0, 0, 0},
+			},
+		},
+		{
+			name: "deposit uses credit before ETH balance",
+			entries: []entry{
+				{CreditLedgerEntry_BondReduction, 1, 0, 8, 0},
+				{CreditLedgerEntry_EthDeposited, 2, 0, 0, 10},
+				{CreditLedgerEntry_Deposit, 3, 0, 16, 0},
+			},
+			expected: []balances{
+				{CreditLedgerEntry_BondReduction, 8, 0, 8, 0},
+				{CreditLedgerEntry_EthDeposited, 0, 10, 8, 10},
+				{CreditLedgerEntry_Deposit, -8, -8, 0, 2},
+			},
+		},
+		{
+			name: "sorted by block then log index",
+			entries: []entry{
+				{CreditLedgerEntry_Deposit, 5, 7, 4, 0},
+				{CreditLedgerEntry_EthWithdrawn, 6, 0, 0, -1},
+				{CreditLedgerEntry_SoloMigration, 5, 2, 24, 0},
+				{CreditLedgerEntry_EthDeposited, 3, 9, 0, 5},
+			},
+			expected: []balances{
+				{CreditLedgerEntry_EthDeposited, 0, 5, 0, 5},
+				{CreditLedgerEntry_SoloMigration, 24, 0, 24, 5},
+				{CreditLedgerEntry_Deposit, -4, 0, 20, 5},
+				{CreditLedgerEntry_EthWithdrawn, 0, -1, 20, 4},
+			},
+		},
+		{
+			name: "deposit with no credit",
+			entries: []entry{
+				{CreditLedgerEntry_EthDeposited, 1, 0, 0, 8},
+				{CreditLedgerEntry_Deposit, 2, 0, 8, 0},
+			},
+			expected: []balances{
+				{CreditLedgerEntry_EthDeposited, 0, 8, 0, 8},
+				{CreditLedgerEntry_Deposit, 0, -8, 0, 0},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ledger := &CreditLedger{
+				Entries:       make([]CreditLedgerEntry, len(test.entries)),
+				CreditBalance: big.NewInt(0),
+				EthBalance:    big.NewInt(0),
+			}
+			for i, e := range test.entries {
+				ledger.Entries[i] = CreditLedgerEntry{
+					Type:             e.entryType,
+					BlockNumber:      e.block,
+					CreditChange:     big.NewInt(e.creditChange),
+					EthBalanceChange: big.NewInt(e.ethChange),
+					logIndex:         e.logIndex,
+				}
+			}
+			ledger.runBalances()
+
+			if len(ledger.Entries) != len(test.expected) {
+				t.Fatalf("expected %d entries, got %d", len(test.expected), len(ledger.Entries))
+			}
+			for i, expected := range test.expected {
+				actual := ledger.Entries[i]
+				if actual.Type != expected.entryType ||
+					actual.CreditChange.Int64() != expected.creditChange ||
+					actual.EthBalanceChange.Int64() != expected.ethChange ||
+					actual.CreditBalance.Int64() != expected.credit ||
+					actual.EthBalance.Int64() != expected.eth {
+					t.Errorf("entry %d: expected %+v, got %s with changes %s/%s and balances %s/%s", i, expected, actual.Type, actual.CreditChange, actual.EthBalanceChange, actual.CreditBalance, actual.EthBalance)
+				}
+			}
+			final := balances{}
+			if len(test.expected) > 0 {
+				final = test.expected[len(test.expected)-1]
+			}
+			if ledger.CreditBalance.Int64() != final.credit || ledger.EthBalance.Int64() != final.eth {
+				t.Errorf("expected final balances %d/%d, got %s/%s", final.credit, final.eth, ledger.CreditBalance, ledger.EthBalance)
+			}
+		})
+	}
+}
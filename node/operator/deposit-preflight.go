@@ -0,0 +1,263 @@
+package operator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashicorp/go-version"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// A rule a node deposit has to satisfy
+type DepositRule string
+
+const (
+	DepositRule_NodeRegistered            DepositRule = "nodeRegistered"
+	DepositRule_DepositsEnabled           DepositRule = "depositsEnabled"
+	DepositRule_FeeDistributorInitialized DepositRule = "feeDistributorInitialized"
+	DepositRule_BondAmount                DepositRule = "bondAmount"
+	DepositRule_DepositValue              DepositRule = "depositValue"
+	DepositRule_MinimumNodeFee            DepositRule = "minimumNodeFee"
+	DepositRule_RplStake                  DepositRule = "rplStake"
+	DepositRule_EthMatchedLimit           DepositRule = "ethMatchedLimit"
+	DepositRule_Credit                    DepositRule = "credit"
+	DepositRule_DepositPoolBalance        DepositRule = "depositPoolBalance"
+	DepositRule_MinipoolCount             DepositRule = "minipoolCount"
+	DepositRule_PubkeyInUse               DepositRule = "pubkeyInUse"
+	DepositRule_MinipoolAddressInUse      DepositRule = "minipoolAddressInUse"
+	DepositRule_QueueCapacity             DepositRule = "queueCapacity"
+)
+
+// A node deposit to check
+type DepositRequest struct {
+	NodeAddress             common.Address        `json:"nodeAddress"`
+	BondAmount              *big.Int              `json:"bondAmount"`
+	MinimumNodeFee          float64               `json:"minimumNodeFee"`
+	Pubkey                  types.ValidatorPubkey `json:"pubkey"`
+	ExpectedMinipoolAddress common.Address        `json:"expectedMinipoolAddress"`
+	Value                   *big.Int              `json:"value"`     // The ETH sent with the transaction
+	UseCredit               bool                  `json:"useCredit"` // True for DepositWithCredit, false for Deposit
+}
+
+// A rule the deposit breaks.
+// For rules about amounts, Required and Available are set and Shortfall is the difference; the units depend on the rule (RPL for RplStake, ETH for the rest).
+// Advisory violations don't make the deposit revert, but change what happens after it (e.g. the minipool waiting in the queue).
+type DepositViolation struct {
+	Rule      DepositRule `json:"rule"`
+	Message   string      `json:"message"`
+	Advisory  bool        `json:"advisory"`
+	Required  *big.Int    `json:"required,omitempty"`
+	Available *big.Int    `json:"available,omitempty"`
+	Shortfall *big.Int    `json:"shortfall,omitempty"`
+}
+
+// The result of checking a node deposit against the current chain state
+type DepositPreflight struct {
+	Request    DepositRequest     `json:"request"`
+	Violations []DepositViolation `json:"violations"`
+
+	// Chain state the rules were checked against
+	NodeRegistered            bool             `json:"nodeRegistered"`
+	DepositsEnabled           bool             `json:"depositsEnabled"`
+	FeeDistributorInitialized bool             `json:"feeDistributorInitialized"`
+	ValidBondAmounts          []*big.Int       `json:"validBondAmounts"`
+	NetworkNodeFee            *big.Int         `json:"networkNodeFee"`
+	LaunchBalance             *big.Int         `json:"launchBalance"`
+	RplPrice                  *big.Int         `json:"rplPrice"`
+	MinimumPerMinipoolStake   *big.Int         `json:"minimumPerMinipoolStake"`
+	RplStake                  *big.Int         `json:"rplStake"`
+	MinimumRplStakeAfter      *big.Int         `json:"minimumRplStakeAfter"` // The node's minimum RPL stake once the new minipool is added
+	EthMatched                *big.Int         `json:"ethMatched"`
+	EthMatchedLimit           *big.Int         `json:"ethMatchedLimit"`
+	UsableCredit              *big.Int         `json:"usableCredit"` // Usable credit and ETH balance on Houston, deposit credit before it
+	DepositPoolBalance        *big.Int         `json:"depositPoolBalance"`
+	ActiveMinipoolCount       *big.Int         `json:"activeMinipoolCount"`
+	MaximumMinipoolCount      *big.Int         `json:"maximumMinipoolCount"`
+	PubkeyMinipool            common.Address   `json:"pubkeyMinipool"`
+	MinipoolExists            bool             `json:"minipoolExists"`
+	QueueLength               *big.Int         `json:"queueLength"`
+	QueueCapacity             *big.Int         `json:"queueCapacity"` // The ETH the deposit pool needs to assign every queued minipool
+	NetworkVersion            *version.Version `json:"-"`
+}
+
+// Check if the deposit breaks any rules that would make it revert
+func (p *DepositPreflight) CanDeposit() bool {
+	for _, violation := range p.Violations {
+		if !violation.Advisory {
+			return false
+		}
+	}
+	return true
+}
+
+// Check a node deposit against everything it depends on, using a single multicall.
+// Every broken rule is reported rather than just the first one the contracts would revert on.
+func CheckDeposit(contracts *state.NetworkContracts, request DepositRequest) (*DepositPreflight, error) {
+	if request.BondAmount == nil {
+		return nil, fmt.Errorf("the deposit request does not have a bond amount")
+	}
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	preflight := &DepositPreflight{
+		Request:        request,
+		NetworkVersion: contracts.Version,
+	}
+	node := request.NodeAddress
+
+	mc := contracts.Multicaller
+	mc.AddCall(contracts.RocketNodeManager, &preflight.NodeRegistered, "getNodeExists", node)
+	mc.AddCall(contracts.RocketNodeManager, &preflight.FeeDistributorInitialized, "getFeeDistributorInitialised", node)
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &preflight.DepositsEnabled, "getDepositEnabled")
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &preflight.MinimumPerMinipoolStake, "getMinimumPerMinipoolStake")
+	mc.AddCall(contracts.RocketNodeDeposit, &preflight.ValidBondAmounts, "getDepositAmounts")
+	mc.AddCall(contracts.RocketNetworkFees, &preflight.NetworkNodeFee, "getNodeFee")
+	mc.AddCall(contracts.RocketNetworkPrices, &preflight.RplPrice, "getRPLPrice")
+	mc.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &preflight.LaunchBalance, "getLaunchBalance")
+	mc.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &preflight.MaximumMinipoolCount, "getMaximumCount")
+	mc.AddCall(contracts.RocketNodeStaking, &preflight.RplStake, "getNodeRPLStake", node)
+	mc.AddCall(contracts.RocketNodeStaking, &preflight.EthMatched, "getNodeETHMatched", node)
+	mc.AddCall(contracts.RocketNodeStaking, &preflight.EthMatchedLimit, "getNodeETHMatchedLimit", node)
+	mc.AddCall(contracts.RocketDepositPool, &preflight.DepositPoolBalance, "getBalance")
+	mc.AddCall(contracts.RocketMinipoolManager, &preflight.ActiveMinipoolCount, "getActiveMinipoolCount")
+	mc.AddCall(contracts.RocketMinipoolManager, &preflight.PubkeyMinipool, "getMinipoolByPubkey", request.Pubkey[:])
+	mc.AddCall(contracts.RocketMinipoolManager, &preflight.MinipoolExists, "getMinipoolExists", request.ExpectedMinipoolAddress)
+	mc.AddCall(contracts.RocketMinipoolQueue, &preflight.QueueLength, "getTotalLength")
+	mc.AddCall(contracts.RocketMinipoolQueue, &preflight.QueueCapacity, "getEffectiveCapacity")
+	if preflight.isHouston() {
+		mc.AddCall(contracts.RocketNodeDeposit, &preflight.UsableCredit, "getNodeUsableCreditAndBalance", node)
+	} else {
+		mc.AddCall(contracts.RocketNodeDeposit, &preflight.UsableCredit, "getNodeDepositCredit", node)
+	}
+	_, err := mc.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+
+	preflight.checkRules()
+	return preflight, nil
+}
+
+// Check the deposit request against the loaded chain state, replacing any previous violations
+func (p *DepositPreflight) checkRules() {
+	p.Violations = []DepositViolation{}
+	request := p.Request
+	node := request.NodeAddress
+
+	// The RPL the node needs once the new minipool borrows from the deposit pool
+	value := request.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	borrowed := big.NewInt(0).Sub(p.LaunchBalance, request.BondAmount)
+	if borrowed.Sign() < 0 {
+		borrowed.SetUint64(0)
+	}
+	ethMatchedAfter := big.NewInt(0).Add(p.EthMatched, borrowed)
+	p.MinimumRplStakeAfter = big.NewInt(0)
+	if p.RplPrice.Sign() > 0 {
+		p.MinimumRplStakeAfter.Mul(ethMatchedAfter, p.MinimumPerMinipoolStake)
+		p.MinimumRplStakeAfter.Div(p.MinimumRplStakeAfter, p.RplPrice)
+	}
+
+	// Flags
+	if !p.NodeRegistered {
+		p.addViolation(DepositRule_NodeRegistered, fmt.Sprintf("node %s is not registered", node.Hex()))
+	}
+	if !p.DepositsEnabled {
+		p.addViolation(DepositRule_DepositsEnabled, "node deposits are currently disabled")
+	}
+	if !p.FeeDistributorInitialized {
+		p.addViolation(DepositRule_FeeDistributorInitialized, "the node's fee distributor has not been initialized")
+	}
+	if p.PubkeyMinipool != (common.Address{}) {
+		p.addViolation(DepositRule_PubkeyInUse, fmt.Sprintf("validator %s is already used by minipool %s", request.Pubkey.Hex(), p.PubkeyMinipool.Hex()))
+	}
+	if p.MinipoolExists {
+		p.addViolation(DepositRule_MinipoolAddressInUse, fmt.Sprintf("minipool %s already exists; use a different salt", request.ExpectedMinipoolAddress.Hex()))
+	}
+	if p.ActiveMinipoolCount.Cmp(p.MaximumMinipoolCount) >= 0 {
+		p.addViolation(DepositRule_MinipoolCount, fmt.Sprintf("the network has reached its maximum of %s active minipools", p.MaximumMinipoolCount.String()))
+	}
+
+	// Bond and fee
+	validBond := false
+	for _, amount := range p.ValidBondAmounts {
+		if amount.Cmp(request.BondAmount) == 0 {
+			validBond = true
+			break
+		}
+	}
+	if !validBond {
+		p.addViolation(DepositRule_BondAmount, fmt.Sprintf("%.6f ETH is not a valid bond amount", eth.WeiToEth(request.BondAmount)))
+	}
+	minimumNodeFee := eth.EthToWei(request.MinimumNodeFee)
+	if minimumNodeFee.Cmp(p.NetworkNodeFee) > 0 {
+		p.addViolation(DepositRule_MinimumNodeFee, fmt.Sprintf("the minimum node fee of %.4f is higher than the current network fee of %.4f", request.MinimumNodeFee, eth.WeiToEth(p.NetworkNodeFee)))
+	}
+
+	// Funding
+	if request.UseCredit {
+		if value.Cmp(request.BondAmount) > 0 {
+			p.addViolation(DepositRule_DepositValue, fmt.Sprintf("%.6f ETH was sent but the bond is only %.6f ETH", eth.WeiToEth(value), eth.WeiToEth(request.BondAmount)))
+		} else {
+			creditNeeded := big.NewInt(0).Sub(request.BondAmount, value)
+			p.addShortfall(DepositRule_Credit, "not enough credit to cover the bond", creditNeeded, p.UsableCredit)
+
+			// Credit is paid out of the deposit pool; Houston's usable credit already accounts for this
+			if !p.isHouston() {
+				p.addShortfall(DepositRule_DepositPoolBalance, "the deposit pool doesn't have enough ETH to cover the credit", creditNeeded, p.DepositPoolBalance)
+			}
+		}
+	} else if value.Cmp(request.BondAmount) != 0 {
+		p.addViolation(DepositRule_DepositValue, fmt.Sprintf("%.6f ETH was sent but Deposit needs exactly the bond of %.6f ETH; use DepositWithCredit to cover the difference with credit", eth.WeiToEth(value), eth.WeiToEth(request.BondAmount)))
+	}
+
+	// Collateral
+	p.addShortfall(DepositRule_RplStake, "not enough RPL staked to collateralize the new minipool", p.MinimumRplStakeAfter, p.RplStake)
+	p.addShortfall(DepositRule_EthMatchedLimit, "the new minipool would take the node over its ETH matched limit", ethMatchedAfter, p.EthMatchedLimit)
+
+	// Queue: the minipool is only assigned once the deposit pool can cover everything ahead of it and its own borrowed ETH
+	queueRequired := big.NewInt(0).Add(p.QueueCapacity, borrowed)
+	if p.DepositPoolBalance.Cmp(queueRequired) < 0 {
+		p.Violations = append(p.Violations, DepositViolation{
+			Rule:      DepositRule_QueueCapacity,
+			Message:   fmt.Sprintf("the new minipool will wait in the queue behind %s minipools until the deposit pool has enough ETH to assign it", p.QueueLength.String()),
+			Advisory:  true,
+			Required:  queueRequired,
+			Available: p.DepositPoolBalance,
+			Shortfall: big.NewInt(0).Sub(queueRequired, p.DepositPoolBalance),
+		})
+	}
+}
+
+// Check if the chain state is from Houston or later
+func (p *DepositPreflight) isHouston() bool {
+	return p.NetworkVersion != nil && p.NetworkVersion.GreaterThanOrEqual(houstonVersion)
+}
+
+// Add a violation of a rule that isn't about an amount
+func (p *DepositPreflight) addViolation(rule DepositRule, message string) {
+	p.Violations = append(p.Violations, DepositViolation{
+		Rule:    rule,
+		Message: message,
+	})
+}
+
+// Add a violation if the available amount doesn't cover the required amount
+func (p *DepositPreflight) addShortfall(rule DepositRule, message string, required *big.Int, available *big.Int) {
+	if available.Cmp(required) >= 0 {
+		return
+	}
+	p.Violations = append(p.Violations, DepositViolation{
+		Rule:      rule,
+		Message:   message,
+		Required:  required,
+		Available: available,
+		Shortfall: big.NewInt(0).Sub(required, available),
+	})
+}
@@ -0,0 +1,194 @@
+package operator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/hashicorp/go-version"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestDepositPreflightCheckRules(t *testing.T) {
+	atlasVersion, _ := version.NewSemver("1.2.0")
+
+	// Every test starts from a valid 8 ETH deposit: 24 ETH borrowed at 0.01 ETH per RPL and 10% needs 240 RPL
+	tests := []struct {
+		name               string
+		modify             func(p *DepositPreflight)
+		expectedRules      []DepositRule
+		expectedShortfalls []float64
+		canDeposit         bool
+	}{
+		{
+			name:       "Valid",
+			canDeposit: true,
+		},
+		{
+			name: "Flags",
+			modify: func(p *DepositPreflight) {
+				p.NodeRegistered = false
+				p.DepositsEnabled = false
+				p.FeeDistributorInitialized = false
+				p.PubkeyMinipool = common.HexToAddress("0x3333333333333333333333333333333333333333")
+				p.MinipoolExists = true
+				p.ActiveMinipoolCount = big.NewInt(14000)
+			},
+			expectedRules: []DepositRule{
+				DepositRule_NodeRegistered,
+				DepositRule_DepositsEnabled,
+				DepositRule_FeeDistributorInitialized,
+				DepositRule_PubkeyInUse,
+				DepositRule_MinipoolAddressInUse,
+				DepositRule_MinipoolCount,
+			},
+			expectedShortfalls: []float64{0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "Invalid bond and fee",
+			modify: func(p *DepositPreflight) {
+				p.Request.BondAmount = eth.EthToWei(4)
+				p.Request.Value = eth.EthToWei(4)
+				p.Request.MinimumNodeFee = 0.15
+				p.RplStake = eth.EthToWei(280)
+			},
+			expectedRules:      []DepositRule{DepositRule_BondAmount, DepositRule_MinimumNodeFee},
+			expectedShortfalls: []float64{0, 0},
+		},
+		{
+			name: "Wrong value without credit",
+			modify: func(p *DepositPreflight) {
+				p.Request.Value = eth.EthToWei(7)
+			},
+			expectedRules:      []DepositRule{DepositRule_DepositValue},
+			expectedShortfalls: []float64{0},
+		},
+		{
+			name: "Too much value with credit",
+			modify: func(p *DepositPreflight) {
+				p.Request.UseCredit = true
+				p.Request.Value = eth.EthToWei(9)
+			},
+			expectedRules:      []DepositRule{DepositRule_DepositValue},
+			expectedShortfalls: []float64{0},
+		},
+		{
+			name: "Atlas credit short of the deposit pool",
+			modify: func(p *DepositPreflight) {
+				p.NetworkVersion = atlasVersion
+				p.Request.UseCredit = true
+				p.Request.Value = eth.EthToWei(2)
+				p.UsableCredit = eth.EthToWei(4)
+				p.DepositPoolBalance = eth.EthToWei(5)
+				p.QueueCapacity = big.NewInt(0)
+			},
+			expectedRules:      []DepositRule{DepositRule_Credit, DepositRule_DepositPoolBalance, DepositRule_QueueCapacity},
+			expectedShortfalls: []float64{2, 1, 19},
+			canDeposit:         false,
+		},
+		{
+			name: "Houston credit ignores the deposit pool",
+			modify: func(p *DepositPreflight) {
+				p.Request.UseCredit = true
+				p.Request.Value = eth.EthToWei(2)
+				p.UsableCredit = eth.EthToWei(6)
+				p.DepositPoolBalance = eth.EthToWei(5)
+				p.QueueCapacity = big.NewInt(0)
+			},
+			expectedRules:      []DepositRule{DepositRule_QueueCapacity},
+			expectedShortfalls: []float64{19},
+			canDeposit:         true,
+		},
+		{
+			name: "Collateral",
+			modify: func(p *DepositPreflight) {
+				p.RplStake = eth.EthToWei(200)
+				p.EthMatched = eth.EthToWei(48)
+			},
+			expectedRules:      []DepositRule{DepositRule_RplStake, DepositRule_EthMatchedLimit},
+			expectedShortfalls: []float64{520, 24},
+		},
+		{
+			name: "Zero RPL price",
+			modify: func(p *DepositPreflight) {
+				p.RplPrice = big.NewInt(0)
+				p.RplStake = big.NewInt(0)
+			},
+			canDeposit: true,
+		},
+		{
+			name: "Queue backlog is advisory",
+			modify: func(p *DepositPreflight) {
+				p.QueueLength = big.NewInt(10)
+				p.QueueCapacity = eth.EthToWei(310)
+			},
+			expectedRules:      []DepositRule{DepositRule_QueueCapacity},
+			expectedShortfalls: []float64{234},
+			canDeposit:         true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			preflight := &DepositPreflight{
+				Request: DepositRequest{
+					NodeAddress:             common.HexToAddress("0x1111111111111111111111111111111111111111"),
+					Pubkey:                  types.ValidatorPubkey{0x01},
+					ExpectedMinipoolAddress: common.HexToAddress("0x2222222222222222222222222222222222222222"),
+					BondAmount:              eth.EthToWei(8),
+					Value:                   eth.EthToWei(8),
+					MinimumNodeFee:          0.1,
+				},
+				NodeRegistered:            true,
+				DepositsEnabled:           true,
+				FeeDistributorInitialized: true,
+				ValidBondAmounts:          []*big.Int{eth.EthToWei(8), eth.EthToWei(16)},
+				NetworkNodeFee:            eth.EthToWei(0.14),
+				LaunchBalance:             eth.EthToWei(32),
+				RplPrice:                  eth.EthToWei(0.01),
+				MinimumPerMinipoolStake:   eth.EthToWei(0.1),
+				RplStake:                  eth.EthToWei(240),
+				EthMatched:                big.NewInt(0),
+				EthMatchedLimit:           eth.EthToWei(48),
+				UsableCredit:              big.NewInt(0),
+				DepositPoolBalance:        eth.EthToWei(100),
+				ActiveMinipoolCount:       big.NewInt(100),
+				MaximumMinipoolCount:      big.NewInt(14000),
+				QueueLength:               big.NewInt(0),
+				QueueCapacity:             big.NewInt(0),
+				NetworkVersion:            houstonVersion,
+			}
+			if test.modify != nil {
+				test.modify(preflight)
+			}
+			preflight.checkRules()
+
+			if len(preflight.Violations) != len(test.expectedRules) {
+				t.Fatalf("expected %d violations but got %d: %v", len(test.expectedRules), len(preflight.Violations), preflight.Violations)
+			}
+			for i, violation := range preflight.Violations {
+				if violation.Rule != test.expectedRules[i] {
+					t.Errorf("violation %d: expected rule %s but got %s", i, test.expectedRules[i], violation.Rule)
+				}
+				shortfall := 0.0
+				if violation.Shortfall != nil {
+					shortfall = eth.WeiToEth(violation.Shortfall)
+				}
+				if shortfall != test.expectedShortfalls[i] {
+					t.Errorf("violation %d: expected shortfall %f but got %f", i, test.expectedShortfalls[i], shortfall)
+				}
+			}
+			if preflight.CanDeposit() != test.canDeposit {
+				t.Errorf("expected CanDeposit to be %t but got %t", test.canDeposit, preflight.CanDeposit())
+			}
+		})
+	}
+}
+
+func TestCheckDepositWithoutBondAmount(t *testing.T) {
+	_, err := CheckDeposit(nil, DepositRequest{})
+	if err == nil {
+		t.Error("expected an error for a request without a bond amount")
+	}
+}
@@ -0,0 +1,189 @@
+package operator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+	"golang.org/x/sync/errgroup"
+)
+
+// Settings
+const (
+	distributorBatchSize   int = 500
+	distributorThreadLimit int = 6
+)
+
+// The fee distributor of a node
+type DistributorStatus struct {
+	NodeAddress            common.Address `json:"nodeAddress"`
+	DistributorAddress     common.Address `json:"distributorAddress"`
+	Initialized            bool           `json:"initialized"`
+	Balance                *big.Int       `json:"balance"`
+	AverageNodeFee         *big.Int       `json:"averageNodeFee"`
+	CollateralisationRatio *big.Int       `json:"collateralisationRatio"`
+	NodeShare              *big.Int       `json:"nodeShare"`
+	UserShare              *big.Int       `json:"userShare"` // Sent to the rETH contract
+}
+
+// Distributors that need attention
+type DistributorReport struct {
+	Threshold     *big.Int            `json:"threshold"`
+	Uninitialized []DistributorStatus `json:"uninitialized"` // Not initialized, with a balance above the threshold
+	Undistributed []DistributorStatus `json:"undistributed"` // Initialized, with a balance above the threshold
+	TotalBalance  *big.Int            `json:"totalBalance"`
+}
+
+// Get the fee distributors of the given nodes, or of every node if nodeAddresses is nil
+func GetDistributorStatuses(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddresses []common.Address) ([]DistributorStatus, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	if nodeAddresses == nil {
+		var err error
+		nodeAddresses, err = node.GetNodeAddressesFast(rp, contracts.Multicaller.ContractAddress, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	count := len(nodeAddresses)
+	statuses := make([]DistributorStatus, count)
+	var wg errgroup.Group
+	wg.SetLimit(distributorThreadLimit)
+	for i := 0; i < count; i += distributorBatchSize {
+		i := i
+		max := i + distributorBatchSize
+		if max > count {
+			max = count
+		}
+
+		wg.Go(func() error {
+			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
+			if err != nil {
+				return err
+			}
+			for j := i; j < max; j++ {
+				status := &statuses[j]
+				status.NodeAddress = nodeAddresses[j]
+				mc.AddCall(contracts.RocketNodeDistributorFactory, &status.DistributorAddress, "getProxyAddress", status.NodeAddress)
+				mc.AddCall(contracts.RocketNodeManager, &status.Initialized, "getFeeDistributorInitialised", status.NodeAddress)
+				mc.AddCall(contracts.RocketNodeManager, &status.AverageNodeFee, "getAverageNodeFee", status.NodeAddress)
+				mc.AddCall(contracts.RocketNodeStaking, &status.CollateralisationRatio, "getNodeETHCollateralisationRatio", status.NodeAddress)
+			}
+			_, err = mc.FlexibleCall(true, opts)
+			if err != nil {
+				return fmt.Errorf("error executing multicall: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting distributor details: %w", err)
+	}
+
+	// Get the balances and split them
+	distributorAddresses := make([]common.Address, count)
+	for i, status := range statuses {
+		distributorAddresses[i] = status.DistributorAddress
+	}
+	balances, err := contracts.BalanceBatcher.GetEthBalances(distributorAddresses, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting distributor balances: %w", err)
+	}
+	for i := range statuses {
+		status := &statuses[i]
+		status.Balance = balances[i]
+		status.NodeShare, status.UserShare = CalculateDistributorShares(status.Balance, status.CollateralisationRatio, status.AverageNodeFee)
+	}
+	return statuses, nil
+}
+
+// Split a distributor balance between the node and rETH the way the distributor does: the node gets its share by collateral
+// plus its average fee (weighted by borrowed ETH, from getAverageNodeFee) on the rest
+func CalculateDistributorShares(balance *big.Int, collateralisationRatio *big.Int, averageNodeFee *big.Int) (*big.Int, *big.Int) {
+	if balance.Sign() == 0 {
+		return big.NewInt(0), big.NewInt(0)
+	}
+	if collateralisationRatio == nil || collateralisationRatio.Sign() == 0 {
+		return big.NewInt(0).Set(balance), big.NewInt(0)
+	}
+
+	nodeShare := big.NewInt(0).Mul(balance, big.NewInt(1e18))
+	nodeShare.Div(nodeShare, collateralisationRatio)
+	userShare := big.NewInt(0).Sub(balance, nodeShare)
+	if averageNodeFee != nil && averageNodeFee.Sign() > 0 {
+		commission := big.NewInt(0).Mul(userShare, averageNodeFee)
+		commission.Div(commission, big.NewInt(1e18))
+		nodeShare.Add(nodeShare, commission)
+		userShare.Sub(balance, nodeShare)
+	}
+	return nodeShare, userShare
+}
+
+// Find the distributors with a balance above the threshold
+func GetDistributorReport(statuses []DistributorStatus, threshold *big.Int) DistributorReport {
+	if threshold == nil {
+		threshold = big.NewInt(0)
+	}
+	report := DistributorReport{
+		Threshold:     threshold,
+		Uninitialized: []DistributorStatus{},
+		Undistributed: []DistributorStatus{},
+		TotalBalance:  big.NewInt(0),
+	}
+	for _, status := range statuses {
+		report.TotalBalance.Add(report.TotalBalance, status.Balance)
+		if status.Balance.Cmp(threshold) <= 0 {
+			continue
+		}
+		if status.Initialized {
+			report.Undistributed = append(report.Undistributed, status)
+		} else {
+			report.Uninitialized = append(report.Uninitialized, status)
+		}
+	}
+	return report
+}
+
+// Estimate the gas of DistributeAll for each distributor
+func EstimateDistributeAllGas(rp *rocketpool.RocketPool, statuses []DistributorStatus, opts *bind.TransactOpts) (map[common.Address]rocketpool.GasInfo, error) {
+	gasInfos := map[common.Address]rocketpool.GasInfo{}
+	for _, status := range statuses {
+		distributor, err := getDistributableDistributor(rp, status)
+		if err != nil {
+			return nil, err
+		}
+		gasInfo, err := distributor.EstimateDistributeGas(opts)
+		if err != nil {
+			return nil, err
+		}
+		gasInfos[status.DistributorAddress] = gasInfo
+	}
+	return gasInfos, nil
+}
+
+// Distribute the balance of each distributor, in order. The sender must be allowed to distribute for every node.
+// If opts has a nonce, it's incremented for each transaction. On error, the hashes of the transactions already sent are returned.
+func DistributeAll(rp *rocketpool.RocketPool, statuses []DistributorStatus, opts *bind.TransactOpts) ([]common.Hash, error) {
+	return rocketpool.SendTransactions(len(statuses), opts, func(i int, txOpts *bind.TransactOpts) (common.Hash, error) {
+		distributor, err := getDistributableDistributor(rp, statuses[i])
+		if err != nil {
+			return common.Hash{}, err
+		}
+		return distributor.Distribute(txOpts)
+	})
+}
+
+// Get the binding for a distributor, making sure it can be distributed
+func getDistributableDistributor(rp *rocketpool.RocketPool, status DistributorStatus) (*node.Distributor, error) {
+	if !status.Initialized {
+		return nil, fmt.Errorf("the distributor for node %s has not been initialized", status.NodeAddress.Hex())
+	}
+	return node.NewDistributor(rp, status.DistributorAddress, nil)
+}
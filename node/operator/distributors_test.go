@@ -0,0 +1,98 @@
+package operator
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestCalculateDistributorShares(t *testing.T) {
+	tests := []struct {
+		name                   string
+		balance                float64
+		collateralisationRatio *big.Int
+		averageNodeFee         *big.Int
+		expectedNodeShare      float64
+		expectedUserShare      float64
+	}{
+		// A ratio of 2 is a 16 ETH bond: half the balance is the node's, plus its fee on the other half
+		{name: "16 ETH bond", balance: 1, collateralisationRatio: eth.EthToWei(2), averageNodeFee: eth.EthToWei(0.15), expectedNodeShare: 0.575, expectedUserShare: 0.425},
+		{name: "8 ETH bond", balance: 1, collateralisationRatio: eth.EthToWei(4), averageNodeFee: eth.EthToWei(0.14), expectedNodeShare: 0.355, expectedUserShare: 0.645},
+		{name: "No fee", balance: 1, collateralisationRatio: eth.EthToWei(2), averageNodeFee: big.NewInt(0), expectedNodeShare: 0.5, expectedUserShare: 0.5},
+		{name: "No minipools", balance: 1, collateralisationRatio: big.NewInt(0), averageNodeFee: big.NewInt(0), expectedNodeShare: 1, expectedUserShare: 0},
+		{name: "Empty", balance: 0, collateralisationRatio: eth.EthToWei(2), averageNodeFee: eth.EthToWei(0.15), expectedNodeShare: 0, expectedUserShare: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			balance := eth.EthToWei(test.balance)
+			nodeShare, userShare := CalculateDistributorShares(balance, test.collateralisationRatio, test.averageNodeFee)
+			if eth.WeiToEth(nodeShare) != test.expectedNodeShare {
+				t.Errorf("expected a node share of %f but got %f", test.expectedNodeShare, eth.WeiToEth(nodeShare))
+			}
+			if eth.WeiToEth(userShare) != test.expectedUserShare {
+				t.Errorf("expected a user share of %f but got %f", test.expectedUserShare, eth.WeiToEth(userShare))
+			}
+			if total := big.NewInt(0).Add(nodeShare, userShare); total.Cmp(balance) != 0 {
+				t.Errorf("expected the shares to add up to %s but got %s", balance.String(), total.String())
+			}
+		})
+	}
+}
+
+func TestGetDistributorReport(t *testing.T) {
+	statuses := []DistributorStatus{
+		{NodeAddress: common.HexToAddress("0x1111111111111111111111111111111111111111"), Initialized: true, Balance: eth.EthToWei(2)},
+		{NodeAddress: common.HexToAddress("0x2222222222222222222222222222222222222222"), Initialized: true, Balance: eth.EthToWei(0.5)},
+		{NodeAddress: common.HexToAddress("0x3333333333333333333333333333333333333333"), Initialized: false, Balance: eth.EthToWei(3)},
+		{NodeAddress: common.HexToAddress("0x4444444444444444444444444444444444444444"), Initialized: false, Balance: eth.EthToWei(1)},
+	}
+
+	tests := []struct {
+		name                  string
+		threshold             *big.Int
+		expectedUndistributed []common.Address
+		expectedUninitialized []common.Address
+	}{
+		{
+			name:                  "No threshold",
+			expectedUndistributed: []common.Address{statuses[0].NodeAddress, statuses[1].NodeAddress},
+			expectedUninitialized: []common.Address{statuses[2].NodeAddress, statuses[3].NodeAddress},
+		},
+		{
+			name:                  "Balances at the threshold are skipped",
+			threshold:             eth.EthToWei(1),
+			expectedUndistributed: []common.Address{statuses[0].NodeAddress},
+			expectedUninitialized: []common.Address{statuses[2].NodeAddress},
+		},
+		{
+			name:                  "Threshold above every balance",
+			threshold:             eth.EthToWei(5),
+			expectedUndistributed: []common.Address{},
+			expectedUninitialized: []common.Address{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := GetDistributorReport(statuses, test.threshold)
+			if eth.WeiToEth(report.TotalBalance) != 6.5 {
+				t.Errorf("expected a total balance of 6.5 but got %f", eth.WeiToEth(report.TotalBalance))
+			}
+			checkNodes := func(kind string, actual []DistributorStatus, expected []common.Address) {
+				if len(actual) != len(expected) {
+					t.Fatalf("expected %d %s distributors but got %d", len(expected), kind, len(actual))
+				}
+				for i, status := range actual {
+					if status.NodeAddress != expected[i] {
+						t.Errorf("%s distributor %d: expected node %s but got %s", kind, i, expected[i].Hex(), status.NodeAddress.Hex())
+					}
+				}
+			}
+			checkNodes("undistributed", report.Undistributed, test.expectedUndistributed)
+			checkNodes("uninitialized", report.Uninitialized, test.expectedUninitialized)
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package operator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/network"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/tokens"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+	"github.com/rocket-pool/rocketpool-go/utils/strings"
+)
+
+// A node setup step
+type OnboardingStepType string
+
+const (
+	OnboardingStep_RegisterNode             OnboardingStepType = "registerNode"
+	OnboardingStep_SetTimezoneLocation      OnboardingStepType = "setTimezoneLocation"
+	OnboardingStep_InitializeFeeDistributor OnboardingStepType = "initializeFeeDistributor"
+	OnboardingStep_InitializeVoting         OnboardingStepType = "initializeVoting"
+	OnboardingStep_JoinSmoothingPool        OnboardingStepType = "joinSmoothingPool"
+	OnboardingStep_ApproveRpl               OnboardingStepType = "approveRpl"
+	OnboardingStep_StakeRpl                 OnboardingStepType = "stakeRpl"
+)
+
+// How the node should be set up
+type OnboardingConfig struct {
+	TimezoneLocation  string          `json:"timezoneLocation"`  // Required if the node isn't registered; if empty, an existing timezone is kept
+	JoinSmoothingPool bool            `json:"joinSmoothingPool"` // Opt in to the smoothing pool; opting out isn't part of onboarding
+	VotingDelegate    *common.Address `json:"votingDelegate"`    // The delegate to initialize voting with under Houston; nil to delegate to the node itself
+	RplStake          *big.Int        `json:"rplStake"`          // The total RPL stake the node should have; nil to skip staking
+}
+
+// A remaining setup step
+type OnboardingStep struct {
+	Type        OnboardingStepType   `json:"type"`
+	Description string               `json:"description"`
+	HoustonOnly bool                 `json:"houstonOnly"`
+	DependsOn   []OnboardingStepType `json:"dependsOn"` // Steps that have to be mined first
+
+	// Whether the step can be sent now: it isn't blocked and nothing it depends on is still remaining
+	Ready   bool   `json:"ready"`
+	Blocked string `json:"blocked"` // Why the step can't be done, if it can't
+
+	// Only ready steps can be estimated
+	GasInfo rocketpool.GasInfo `json:"gasInfo"`
+
+	estimate func(opts *bind.TransactOpts) (rocketpool.GasInfo, error)
+	execute  func(opts *bind.TransactOpts) (common.Hash, error)
+}
+
+// A sent setup step
+type OnboardingStepResult struct {
+	Type   OnboardingStepType `json:"type"`
+	TxHash common.Hash        `json:"txHash"`
+}
+
+// The remaining setup steps of a node, in order.
+// Execution sends every ready step; once those are mined, load a new plan to resume from where the node left off.
+type OnboardingPlan struct {
+	NodeAddress  common.Address   `json:"nodeAddress"`
+	HoustonRules bool             `json:"houstonRules"`
+	Config       OnboardingConfig `json:"config"`
+
+	// Node state
+	Registered                bool     `json:"registered"`
+	TimezoneLocation          string   `json:"timezoneLocation"`
+	FeeDistributorInitialized bool     `json:"feeDistributorInitialized"`
+	VotingInitialized         bool     `json:"votingInitialized"`
+	SmoothingPoolOptedIn      bool     `json:"smoothingPoolOptedIn"`
+	RplStake                  *big.Int `json:"rplStake"`
+	RplBalance                *big.Int `json:"rplBalance"`
+	RplAllowance              *big.Int `json:"rplAllowance"`
+
+	// Network state
+	RegistrationEnabled              bool `json:"registrationEnabled"`
+	SmoothingPoolRegistrationEnabled bool `json:"smoothingPoolRegistrationEnabled"`
+
+	Steps []OnboardingStep `json:"steps"`
+}
+
+// Plan the remaining setup steps of the node sending opts, and estimate the gas of the ready ones
+func GetOnboardingPlan(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, config OnboardingConfig, opts *bind.TransactOpts) (*OnboardingPlan, error) {
+	callOpts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	nodeAddress := opts.From
+	plan := &OnboardingPlan{
+		NodeAddress:  nodeAddress,
+		HoustonRules: contracts.Version != nil && contracts.Version.GreaterThanOrEqual(houstonVersion),
+		Config:       config,
+		Steps:        []OnboardingStep{},
+	}
+
+	stakingAddress := *contracts.RocketNodeStaking.Address
+	mc := contracts.Multicaller
+	mc.AddCall(contracts.RocketNodeManager, &plan.Registered, "getNodeExists", nodeAddress)
+	mc.AddCall(contracts.RocketNodeManager, &plan.TimezoneLocation, "getNodeTimezoneLocation", nodeAddress)
+	mc.AddCall(contracts.RocketNodeManager, &plan.FeeDistributorInitialized, "getFeeDistributorInitialised", nodeAddress)
+	mc.AddCall(contracts.RocketNodeManager, &plan.SmoothingPoolOptedIn, "getSmoothingPoolRegistrationState", nodeAddress)
+	mc.AddCall(contracts.RocketNodeStaking, &plan.RplStake, "getNodeRPLStake", nodeAddress)
+	mc.AddCall(contracts.RocketTokenRPL, &plan.RplBalance, "balanceOf", nodeAddress)
+	mc.AddCall(contracts.RocketTokenRPL, &plan.RplAllowance, "allowance", nodeAddress, stakingAddress)
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &plan.RegistrationEnabled, "getRegistrationEnabled")
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &plan.SmoothingPoolRegistrationEnabled, "getSmoothingPoolRegistrationEnabled")
+	if plan.HoustonRules {
+		mc.AddCall(contracts.RocketNetworkVoting, &plan.VotingInitialized, "getVotingInitialised", nodeAddress)
+	}
+	_, err := mc.FlexibleCall(true, callOpts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	plan.TimezoneLocation = strings.Sanitize(plan.TimezoneLocation)
+
+	if err := plan.buildSteps(rp, stakingAddress); err != nil {
+		return nil, err
+	}
+
+	// Estimate the steps that can be sent now
+	for i := range plan.Steps {
+		step := &plan.Steps[i]
+		if !step.Ready {
+			continue
+		}
+		step.GasInfo, err = step.estimate(opts)
+		if err != nil {
+			return nil, fmt.Errorf("error estimating gas for step %s: %w", step.Type, err)
+		}
+	}
+	return plan, nil
+}
+
+// Build the remaining setup steps from the loaded node and network state, and mark the ones that can be sent now
+func (p *OnboardingPlan) buildSteps(rp *rocketpool.RocketPool, stakingAddress common.Address) error {
+	config := p.Config
+	p.Steps = []OnboardingStep{}
+
+	// Registration
+	if !p.Registered {
+		if config.TimezoneLocation == "" {
+			return fmt.Errorf("a timezone location is required to register node %s", p.NodeAddress.Hex())
+		}
+		step := OnboardingStep{
+			Type:        OnboardingStep_RegisterNode,
+			Description: fmt.Sprintf("Register the node in the %s timezone", config.TimezoneLocation),
+			estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return node.EstimateRegisterNodeGas(rp, config.TimezoneLocation, opts)
+			},
+			execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+				return node.RegisterNode(rp, config.TimezoneLocation, opts)
+			},
+		}
+		if !p.RegistrationEnabled {
+			step.Blocked = "node registrations are currently disabled"
+		}
+		p.Steps = append(p.Steps, step)
+	} else if config.TimezoneLocation != "" && config.TimezoneLocation != p.TimezoneLocation {
+		p.Steps = append(p.Steps, OnboardingStep{
+			Type:        OnboardingStep_SetTimezoneLocation,
+			Description: fmt.Sprintf("Change the node's timezone from %s to %s", p.TimezoneLocation, config.TimezoneLocation),
+			estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return node.EstimateSetTimezoneLocationGas(rp, config.TimezoneLocation, opts)
+			},
+			execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+				return node.SetTimezoneLocation(rp, config.TimezoneLocation, opts)
+			},
+		})
+	}
+
+	// Fee distributor; registration initializes it for new nodes, so this is only needed by nodes that registered before it existed
+	if p.Registered && !p.FeeDistributorInitialized {
+		p.Steps = append(p.Steps, OnboardingStep{
+			Type:        OnboardingStep_InitializeFeeDistributor,
+			Description: "Initialize the node's fee distributor",
+			estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return node.EstimateInitializeFeeDistributorGas(rp, opts)
+			},
+			execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+				return node.InitializeFeeDistributor(rp, opts)
+			},
+		})
+	}
+
+	// Voting
+	if p.HoustonRules && !p.VotingInitialized {
+		step := OnboardingStep{
+			Type:        OnboardingStep_InitializeVoting,
+			Description: "Initialize the node's voting power",
+			HoustonOnly: true,
+			DependsOn:   []OnboardingStepType{OnboardingStep_RegisterNode},
+			estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return network.EstimateInitializeVotingGas(rp, opts)
+			},
+			execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+				return network.InitializeVoting(rp, opts)
+			},
+		}
+		if config.VotingDelegate != nil {
+			delegate := *config.VotingDelegate
+			step.Description = fmt.Sprintf("Initialize the node's voting power, delegated to %s", delegate.Hex())
+			step.estimate = func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return network.EstimateInitializeVotingWithDelegateGas(rp, delegate, opts)
+			}
+			step.execute = func(opts *bind.TransactOpts) (common.Hash, error) {
+				return network.InitializeVotingWithDelegate(rp, delegate, opts)
+			}
+		}
+		p.Steps = append(p.Steps, step)
+	}
+
+	// Smoothing pool
+	if config.JoinSmoothingPool && !p.SmoothingPoolOptedIn {
+		step := OnboardingStep{
+			Type:        OnboardingStep_JoinSmoothingPool,
+			Description: "Opt in to the smoothing pool",
+			DependsOn:   []OnboardingStepType{OnboardingStep_RegisterNode},
+			estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return node.EstimateSetSmoothingPoolRegistrationStateGas(rp, true, opts)
+			},
+			execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+				return node.SetSmoothingPoolRegistrationState(rp, true, opts)
+			},
+		}
+		if !p.SmoothingPoolRegistrationEnabled {
+			step.Blocked = "smoothing pool registration changes are currently disabled"
+		}
+		p.Steps = append(p.Steps, step)
+	}
+
+	// RPL stake
+	if config.RplStake != nil && config.RplStake.Cmp(p.RplStake) > 0 {
+		amount := big.NewInt(0).Sub(config.RplStake, p.RplStake)
+		var blocked string
+		if p.RplBalance.Cmp(amount) < 0 {
+			blocked = fmt.Sprintf("the node needs %s RPL to stake but only has %s", amount.String(), p.RplBalance.String())
+		}
+		if p.RplAllowance.Cmp(amount) < 0 {
+			p.Steps = append(p.Steps, OnboardingStep{
+				Type:        OnboardingStep_ApproveRpl,
+				Description: fmt.Sprintf("Allow the staking contract to transfer %s RPL", amount.String()),
+				Blocked:     blocked,
+				estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+					return tokens.EstimateApproveRPLGas(rp, stakingAddress, amount, opts)
+				},
+				execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+					return tokens.ApproveRPL(rp, stakingAddress, amount, opts)
+				},
+			})
+		}
+		p.Steps = append(p.Steps, OnboardingStep{
+			Type:        OnboardingStep_StakeRpl,
+			Description: fmt.Sprintf("Stake %s RPL", amount.String()),
+			DependsOn:   []OnboardingStepType{OnboardingStep_RegisterNode, OnboardingStep_ApproveRpl},
+			Blocked:     blocked,
+			estimate: func(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+				return node.EstimateStakeGas(rp, amount, opts)
+			},
+			execute: func(opts *bind.TransactOpts) (common.Hash, error) {
+				return node.StakeRPL(rp, amount, opts)
+			},
+		})
+	}
+
+	for i := range p.Steps {
+		step := &p.Steps[i]
+		step.Ready = step.Blocked == "" && !p.hasRemainingDependency(step)
+	}
+	return nil
+}
+
+// Check if the node has no setup steps left
+func (p *OnboardingPlan) IsComplete() bool {
+	return len(p.Steps) == 0
+}
+
+// Get the steps that can be sent now
+func (p *OnboardingPlan) GetReadySteps() []OnboardingStep {
+	steps := []OnboardingStep{}
+	for _, step := range p.Steps {
+		if step.Ready {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// Send every ready step, in order. Wait for them to be mined, then load a new plan to continue.
+// If opts has a nonce, it's incremented for each transaction. On error, the steps already sent are returned.
+func (p *OnboardingPlan) ExecuteReadySteps(opts *bind.TransactOpts) ([]OnboardingStepResult, error) {
+	if opts.From != p.NodeAddress {
+		return nil, fmt.Errorf("the plan is for node %s but the transactions would be sent by %s", p.NodeAddress.Hex(), opts.From.Hex())
+	}
+	steps := p.GetReadySteps()
+	hashes, err := rocketpool.SendTransactions(len(steps), opts, func(i int, txOpts *bind.TransactOpts) (common.Hash, error) {
+		hash, err := steps[i].execute(txOpts)
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("error executing step %s: %w", steps[i].Type, err)
+		}
+		return hash, nil
+	})
+	results := make([]OnboardingStepResult, len(hashes))
+	for i, hash := range hashes {
+		results[i] = OnboardingStepResult{
+			Type:   steps[i].Type,
+			TxHash: hash,
+		}
+	}
+	return results, err
+}
+
+// Check if any of the steps a step depends on are still in the plan
+func (p *OnboardingPlan) hasRemainingDependency(step *OnboardingStep) bool {
+	for _, dependency := range step.DependsOn {
+		for _, other := range p.Steps {
+			if other.Type == dependency {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package operator
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestOnboardingPlanBuildSteps(t *testing.T) {
+	stakingAddress := common.HexToAddress("0x9999999999999999999999999999999999999999")
+
+	tests := []struct {
+		name          string
+		modify        func(p *OnboardingPlan)
+		expectError   bool
+		expectedSteps []OnboardingStepType
+		expectedReady []OnboardingStepType
+		expectedBlock []OnboardingStepType
+	}{
+		{
+			name:          "complete",
+			expectedSteps: []OnboardingStepType{},
+			expectedReady: []OnboardingStepType{},
+			expectedBlock: []OnboardingStepType{},
+		},
+		{
+			name: "new node",
+			modify: func(p *OnboardingPlan) {
+				p.Registered = false
+				p.TimezoneLocation = ""
+				p.FeeDistributorInitialized = false
+				p.VotingInitialized = false
+				p.SmoothingPoolOptedIn = false
+				p.RplStake = big.NewInt(0)
+				p.RplAllowance = big.NewInt(0)
+			},
+			expectedSteps: []OnboardingStepType{OnboardingStep_RegisterNode, OnboardingStep_InitializeVoting, OnboardingStep_JoinSmoothingPool, OnboardingStep_ApproveRpl, OnboardingStep_StakeRpl},
+			expectedReady: []OnboardingStepType{OnboardingStep_RegisterNode, OnboardingStep_ApproveRpl},
+			expectedBlock: []OnboardingStepType{},
+		},
+		{
+			name: "new node without a timezone",
+			modify: func(p *OnboardingPlan) {
+				p.Registered = false
+				p.Config.TimezoneLocation = ""
+			},
+			expectError: true,
+		},
+		{
+			name: "registrations disabled",
+			modify: func(p *OnboardingPlan) {
+				p.Registered = false
+				p.RegistrationEnabled = false
+			},
+			expectedSteps: []OnboardingStepType{OnboardingStep_RegisterNode},
+			expectedReady: []OnboardingStepType{},
+			expectedBlock: []OnboardingStepType{OnboardingStep_RegisterNode},
+		},
+		{
+			name: "registered node before Houston",
+			modify: func(p *OnboardingPlan) {
+				p.HoustonRules = false
+				p.VotingInitialized = false
+				p.TimezoneLocation = "Etc/UTC"
+				p.FeeDistributorInitialized = false
+				p.RplStake = big.NewInt(40)
+			},
+			expectedSteps: []OnboardingStepType{OnboardingStep_SetTimezoneLocation, OnboardingStep_InitializeFeeDistributor, OnboardingStep_StakeRpl},
+			expectedReady: []OnboardingStepType{OnboardingStep_SetTimezoneLocation, OnboardingStep_InitializeFeeDistributor, OnboardingStep_StakeRpl},
+			expectedBlock: []OnboardingStepType{},
+		},
+		{
+			name: "registered node under Houston",
+			modify: func(p *OnboardingPlan) {
+				p.VotingInitialized = false
+				p.SmoothingPoolOptedIn = false
+			},
+			expectedSteps: []OnboardingStepType{OnboardingStep_InitializeVoting, OnboardingStep_JoinSmoothingPool},
+			expectedReady: []OnboardingStepType{OnboardingStep_InitializeVoting, OnboardingStep_JoinSmoothingPool},
+			expectedBlock: []OnboardingStepType{},
+		},
+		{
+			name: "keeps the existing timezone",
+			modify: func(p *OnboardingPlan) {
+				p.Config.TimezoneLocation = ""
+			},
+			expectedSteps: []OnboardingStepType{},
+			expectedReady: []OnboardingStepType{},
+			expectedBlock: []OnboardingStepType{},
+		},
+		{
+			name: "smoothing pool registration disabled",
+			modify: func(p *OnboardingPlan) {
+				p.SmoothingPoolOptedIn = false
+				p.SmoothingPoolRegistrationEnabled = false
+			},
+			expectedSteps: []OnboardingStepType{OnboardingStep_JoinSmoothingPool},
+			expectedReady: []OnboardingStepType{},
+			expectedBlock: []OnboardingStepType{OnboardingStep_JoinSmoothingPool},
+		},
+		{
+			name: "not enough RPL",
+			modify: func(p *OnboardingPlan) {
+				p.RplStake = big.NewInt(0)
+				p.RplBalance = big.NewInt(50)
+				p.RplAllowance = big.NewInt(0)
+			},
+			expectedSteps: []OnboardingStepType{OnboardingStep_ApproveRpl, OnboardingStep_StakeRpl},
+			expectedReady: []OnboardingStepType{},
+			expectedBlock: []OnboardingStepType{OnboardingStep_ApproveRpl, OnboardingStep_StakeRpl},
+		},
+		{
+			name: "not joining the smoothing pool",
+			modify: func(p *OnboardingPlan) {
+				p.Config.JoinSmoothingPool = false
+				p.SmoothingPoolOptedIn = false
+			},
+			expectedSteps: []OnboardingStepType{},
+			expectedReady: []OnboardingStepType{},
+			expectedBlock: []OnboardingStepType{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &OnboardingPlan{
+				NodeAddress:  common.HexToAddress("0x1111111111111111111111111111111111111111"),
+				HoustonRules: true,
+				Config: OnboardingConfig{
+					TimezoneLocation:  "Europe/Berlin",
+					JoinSmoothingPool: true,
+					RplStake:          big.NewInt(100),
+				},
+				Registered:                       true,
+				TimezoneLocation:                 "Europe/Berlin",
+				FeeDistributorInitialized:        true,
+				VotingInitialized:                true,
+				SmoothingPoolOptedIn:             true,
+				RplStake:                         big.NewInt(100),
+				RplBalance:                       big.NewInt(200),
+				RplAllowance:                     big.NewInt(100),
+				RegistrationEnabled:              true,
+				SmoothingPoolRegistrationEnabled: true,
+			}
+			if test.modify != nil {
+				test.modify(plan)
+			}
+
+			err := plan.buildSteps(nil, stakingAddress)
+			if test.expectError {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			steps := []OnboardingStepType{}
+			ready := []OnboardingStepType{}
+			blocked := []OnboardingStepType{}
+			for _, step := range plan.Steps {
+				steps = append(steps, step.Type)
+				if step.Ready {
+					ready = append(ready, step.Type)
+				}
+				if step.Blocked != "" {
+					blocked = append(blocked, step.Type)
+				}
+			}
+			if !reflect.DeepEqual(steps, test.expectedSteps) {
+				t.Errorf("expected steps %v but got %v", test.expectedSteps, steps)
+			}
+			if !reflect.DeepEqual(ready, test.expectedReady) {
+				t.Errorf("expected ready steps %v but got %v", test.expectedReady, ready)
+			}
+			if !reflect.DeepEqual(blocked, test.expectedBlock) {
+				t.Errorf("expected blocked steps %v but got %v", test.expectedBlock, blocked)
+			}
+			if plan.IsComplete() != (len(test.expectedSteps) == 0) {
+				t.Errorf("unexpected completion %t", plan.IsComplete())
+			}
+			if readySteps := plan.GetReadySteps(); len(readySteps) != len(test.expectedReady) {
+				t.Errorf("expected %d ready steps but got %d", len(test.expectedReady), len(readySteps))
+			}
+		})
+	}
+}
+
+func TestOnboardingPlanHasRemainingDependency(t *testing.T) {
+	plan := &OnboardingPlan{
+		Steps: []OnboardingStep{
+			{Type: OnboardingStep_ApproveRpl},
+			{Type: OnboardingStep_StakeRpl, DependsOn: []OnboardingStepType{OnboardingStep_RegisterNode, OnboardingStep_ApproveRpl}},
+			{Type: OnboardingStep_JoinSmoothingPool, DependsOn: []OnboardingStepType{OnboardingStep_RegisterNode}},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		step     OnboardingStep
+		expected bool
+	}{
+		{name: "no dependencies", step: plan.Steps[0]},
+		{name: "remaining dependency", step: plan.Steps[1], expected: true},
+		{name: "dependency already done", step: plan.Steps[2]},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := plan.hasRemainingDependency(&test.step); actual != test.expected {
+				t.Errorf("expected %t but got %t", test.expected, actual)
+			}
+		})
+	}
+}
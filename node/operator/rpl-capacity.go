@@ -0,0 +1,189 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// The bond of the smallest minipool
+var Leb8BondAmount = eth.EthToWei(8)
+
+// The RPL staking position of a node, for planning stakes and withdrawals at a given RPL price
+type RplCapacityPlan struct {
+	NodeAddress  common.Address `json:"nodeAddress"`
+	HoustonRules bool           `json:"houstonRules"`
+	BlockTime    time.Time      `json:"blockTime"`
+	RplPrice     *big.Int       `json:"rplPrice"` // The price all calculations use; defaults to the network price
+
+	// Node
+	RplStake    *big.Int  `json:"rplStake"`
+	RplLocked   *big.Int  `json:"rplLocked"`
+	EthMatched  *big.Int  `json:"ethMatched"`  // ETH borrowed from the deposit pool
+	EthProvided *big.Int  `json:"ethProvided"` // ETH bonded by the node
+	StakedTime  time.Time `json:"stakedTime"`
+
+	// Network
+	MinimumPerMinipoolStake *big.Int      `json:"minimumPerMinipoolStake"`
+	MaximumPerMinipoolStake *big.Int      `json:"maximumPerMinipoolStake"`
+	LaunchBalance           *big.Int      `json:"launchBalance"`
+	WithdrawalCooldown      time.Duration `json:"withdrawalCooldown"`
+}
+
+// Load a node's RPL staking position. If rplPrice is nil, the network's RPL price is used.
+func NewRplCapacityPlan(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddress common.Address, rplPrice *big.Int) (*RplCapacityPlan, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	plan := &RplCapacityPlan{
+		NodeAddress:  nodeAddress,
+		HoustonRules: contracts.Version != nil && contracts.Version.GreaterThanOrEqual(houstonVersion),
+		RplLocked:    big.NewInt(0),
+	}
+
+	var networkRplPrice *big.Int
+	var stakedTime *big.Int
+	var cooldown *big.Int
+	mc := contracts.Multicaller
+	mc.AddCall(contracts.RocketNetworkPrices, &networkRplPrice, "getRPLPrice")
+	mc.AddCall(contracts.RocketNodeStaking, &plan.RplStake, "getNodeRPLStake", nodeAddress)
+	mc.AddCall(contracts.RocketNodeStaking, &plan.EthMatched, "getNodeETHMatched", nodeAddress)
+	mc.AddCall(contracts.RocketNodeStaking, &plan.EthProvided, "getNodeETHProvided", nodeAddress)
+	mc.AddCall(contracts.RocketNodeStaking, &stakedTime, "getNodeRPLStakedTime", nodeAddress)
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &plan.MinimumPerMinipoolStake, "getMinimumPerMinipoolStake")
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &plan.MaximumPerMinipoolStake, "getMaximumPerMinipoolStake")
+	mc.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &plan.LaunchBalance, "getLaunchBalance")
+	mc.AddCall(contracts.RocketRewardsPool, &cooldown, "getClaimIntervalTime")
+	if plan.HoustonRules {
+		mc.AddCall(contracts.RocketNodeStaking, &plan.RplLocked, "getNodeRPLLocked", nodeAddress)
+	}
+	_, err := mc.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+
+	header, err := rp.Client.HeaderByNumber(context.Background(), contracts.ElBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %s: %w", contracts.ElBlockNumber.String(), err)
+	}
+	plan.BlockTime = time.Unix(int64(header.Time), 0)
+	plan.StakedTime = time.Unix(stakedTime.Int64(), 0)
+	plan.WithdrawalCooldown = time.Duration(cooldown.Uint64()) * time.Second
+	plan.RplPrice = rplPrice
+	if plan.RplPrice == nil {
+		plan.RplPrice = networkRplPrice
+	}
+	return plan, nil
+}
+
+// Get the minimum RPL stake for the node's current minipools
+func (p *RplCapacityPlan) GetMinimumRplStake() *big.Int {
+	return p.getRplForEth(p.EthMatched, p.MinimumPerMinipoolStake)
+}
+
+// Get the RPL stake above which extra RPL doesn't earn more rewards
+func (p *RplCapacityPlan) GetMaximumEffectiveRplStake() *big.Int {
+	return p.getRplForEth(p.EthMatched, p.MaximumPerMinipoolStake)
+}
+
+// Get the stake the node can't withdraw below.
+// This is the maximum per-minipool stake against borrowed ETH under Atlas, and against bonded ETH under Houston.
+func (p *RplCapacityPlan) GetWithdrawalFloor() *big.Int {
+	if p.HoustonRules {
+		return p.getRplForEth(p.EthProvided, p.MaximumPerMinipoolStake)
+	}
+	return p.GetMaximumEffectiveRplStake()
+}
+
+// Get the RPL the node can withdraw, ignoring the cooldown.
+// Under Houston, locked RPL has to stay staked on top of the floor; RPL can't be locked before Houston.
+func (p *RplCapacityPlan) GetWithdrawableRpl() *big.Int {
+	withdrawable := big.NewInt(0).Sub(p.RplStake, p.GetWithdrawalFloor())
+	if p.HoustonRules {
+		withdrawable.Sub(withdrawable, p.RplLocked)
+	}
+	if withdrawable.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return withdrawable
+}
+
+// Get the time the withdrawal cooldown ends
+func (p *RplCapacityPlan) GetWithdrawalTime() time.Time {
+	return p.StakedTime.Add(p.WithdrawalCooldown)
+}
+
+// Check if the node can withdraw RPL at the given time, and if not, how long it has to wait
+func (p *RplCapacityPlan) CanWithdrawAt(t time.Time) (bool, time.Duration) {
+	withdrawalTime := p.GetWithdrawalTime()
+	if t.Before(withdrawalTime) {
+		return false, withdrawalTime.Sub(t)
+	}
+	return p.GetWithdrawableRpl().Sign() > 0, 0
+}
+
+// Get the value of the node's stake as a fraction of the ETH it has borrowed; 0 if it hasn't borrowed any
+func (p *RplCapacityPlan) GetCollateralRatio() float64 {
+	return p.getStakeRatio(p.EthMatched)
+}
+
+// Get the value of the node's stake as a fraction of the ETH it has bonded; 0 if it hasn't bonded any
+func (p *RplCapacityPlan) GetBondedCollateralRatio() float64 {
+	return p.getStakeRatio(p.EthProvided)
+}
+
+// Get the additional RPL the node needs to stake to create the given number of minipools with the given bond
+func (p *RplCapacityPlan) GetRplRequiredForMinipools(count uint64, bondAmount *big.Int) *big.Int {
+	borrowed := big.NewInt(0).Sub(p.LaunchBalance, bondAmount)
+	borrowed.Mul(borrowed, big.NewInt(0).SetUint64(count))
+	borrowed.Add(borrowed, p.EthMatched)
+	required := p.getRplForEth(borrowed, p.MinimumPerMinipoolStake)
+	required.Sub(required, p.RplStake)
+	if required.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return required
+}
+
+// Get the number of minipools with the given bond the node can create with its current stake
+func (p *RplCapacityPlan) GetMinipoolCapacity(bondAmount *big.Int) uint64 {
+	borrowedPerMinipool := big.NewInt(0).Sub(p.LaunchBalance, bondAmount)
+	if borrowedPerMinipool.Sign() <= 0 || p.MinimumPerMinipoolStake.Sign() == 0 {
+		return 0
+	}
+
+	// The most ETH the stake can cover at the minimum collateral
+	borrowLimit := big.NewInt(0).Mul(p.RplStake, p.RplPrice)
+	borrowLimit.Div(borrowLimit, p.MinimumPerMinipoolStake)
+	borrowLimit.Sub(borrowLimit, p.EthMatched)
+	if borrowLimit.Sign() <= 0 {
+		return 0
+	}
+	return borrowLimit.Div(borrowLimit, borrowedPerMinipool).Uint64()
+}
+
+// Get the RPL worth the given fraction of an ETH amount at the plan's price
+func (p *RplCapacityPlan) getRplForEth(ethAmount *big.Int, fraction *big.Int) *big.Int {
+	if p.RplPrice.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	rpl := big.NewInt(0).Mul(ethAmount, fraction)
+	return rpl.Div(rpl, p.RplPrice)
+}
+
+// Get the ETH value of the node's stake as a fraction of an ETH amount
+func (p *RplCapacityPlan) getStakeRatio(ethAmount *big.Int) float64 {
+	if ethAmount.Sign() == 0 {
+		return 0
+	}
+	value := big.NewInt(0).Mul(p.RplStake, p.RplPrice)
+	value.Div(value, ethAmount)
+	return eth.WeiToEth(value)
+}
@@ -0,0 +1,66 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestRplCapacityPlanWithdrawals(t *testing.T) {
+	stakedTime := time.Unix(1700000000, 0)
+	cooldown := 28 * 24 * time.Hour
+
+	// At 0.01 ETH per RPL and 150%, the floor is 3600 RPL against 24 borrowed ETH and 1200 RPL against 8 bonded ETH
+	tests := []struct {
+		name                 string
+		houston              bool
+		stake                float64
+		locked               float64
+		expectedFloor        float64
+		expectedWithdrawable float64
+	}{
+		{name: "Atlas", stake: 5000, expectedFloor: 3600, expectedWithdrawable: 1400},
+		{name: "Atlas under the floor", stake: 3000, expectedFloor: 3600, expectedWithdrawable: 0},
+		{name: "Atlas ignores locked RPL", stake: 5000, locked: 1000, expectedFloor: 3600, expectedWithdrawable: 1400},
+		{name: "Houston", houston: true, stake: 5000, expectedFloor: 1200, expectedWithdrawable: 3800},
+		{name: "Houston with locked RPL", houston: true, stake: 5000, locked: 1000, expectedFloor: 1200, expectedWithdrawable: 2800},
+		{name: "Houston with locked RPL above the excess", houston: true, stake: 5000, locked: 4000, expectedFloor: 1200, expectedWithdrawable: 0},
+		{name: "Houston under the floor", houston: true, stake: 1000, expectedFloor: 1200, expectedWithdrawable: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan := &RplCapacityPlan{
+				HoustonRules:            test.houston,
+				RplPrice:                eth.EthToWei(0.01),
+				RplStake:                eth.EthToWei(test.stake),
+				RplLocked:               eth.EthToWei(test.locked),
+				EthMatched:              eth.EthToWei(24),
+				EthProvided:             eth.EthToWei(8),
+				StakedTime:              stakedTime,
+				MinimumPerMinipoolStake: eth.EthToWei(0.1),
+				MaximumPerMinipoolStake: eth.EthToWei(1.5),
+				LaunchBalance:           eth.EthToWei(32),
+				WithdrawalCooldown:      cooldown,
+			}
+			if floor := plan.GetWithdrawalFloor(); floor.Cmp(eth.EthToWei(test.expectedFloor)) != 0 {
+				t.Errorf("expected a floor of %.0f RPL, got %s", test.expectedFloor, floor)
+			}
+			withdrawable := plan.GetWithdrawableRpl()
+			if withdrawable.Cmp(eth.EthToWei(test.expectedWithdrawable)) != 0 {
+				t.Errorf("expected %.0f RPL to be withdrawable, got %s", test.expectedWithdrawable, withdrawable)
+			}
+
+			// The cooldown applies first, then the withdrawable amount
+			canWithdraw, wait := plan.CanWithdrawAt(stakedTime.Add(24 * time.Hour))
+			if canWithdraw || wait != cooldown-24*time.Hour {
+				t.Errorf("expected to wait %s during the cooldown, got %t and %s", cooldown-24*time.Hour, canWithdraw, wait)
+			}
+			canWithdraw, wait = plan.CanWithdrawAt(stakedTime.Add(cooldown))
+			if canWithdraw != (test.expectedWithdrawable > 0) || wait != 0 {
+				t.Errorf("expected %t after the cooldown, got %t and %s", test.expectedWithdrawable > 0, canWithdraw, wait)
+			}
+		})
+	}
+}
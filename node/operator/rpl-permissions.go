@@ -0,0 +1,336 @@
+package operator
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// The RPLStaked event before Houston, which didn't record who staked
+var legacyRplStakedEventID = crypto.Keccak256Hash([]byte("RPLStaked(address,uint256,uint256)"))
+
+// An RPL permission of a node
+type RplPermissionSetting string
+
+const (
+	RplPermissionSetting_StakeRplFor RplPermissionSetting = "stakeRplFor"
+	RplPermissionSetting_RplLocking  RplPermissionSetting = "rplLocking"
+)
+
+// A change to one of a node's RPL permissions
+type RplPermissionChange struct {
+	Setting     RplPermissionSetting `json:"setting"`
+	Caller      common.Address       `json:"caller"` // Empty for RPL locking, which isn't per caller
+	Allowed     bool                 `json:"allowed"`
+	Time        time.Time            `json:"time"`
+	BlockNumber uint64               `json:"blockNumber"`
+	TxHash      common.Hash          `json:"txHash"`
+}
+
+// An address that has staked RPL for a node
+type RplStaker struct {
+	Address     common.Address `json:"address"`
+	Amount      *big.Int       `json:"amount"`
+	StakeCount  int            `json:"stakeCount"`
+	LastStake   time.Time      `json:"lastStake"`
+	IsNode      bool           `json:"isNode"`
+	Allowed     bool           `json:"allowed"` // Whether the address can currently stake for the node; the node and its RPL withdrawal address always can
+	LastChanged time.Time      `json:"lastChanged"`
+}
+
+// The RPL permissions of a node, rebuilt from the rocketNodeStaking events
+type RplPermissions struct {
+	NodeAddress common.Address `json:"nodeAddress"`
+
+	// The node's RPL withdrawal address, which can always stake for the node and controls RPL locking once it's set (Houston only)
+	RplWithdrawalAddressIsSet bool           `json:"rplWithdrawalAddressIsSet"`
+	RplWithdrawalAddress      common.Address `json:"rplWithdrawalAddress"`
+
+	// Addresses currently allowed to stake RPL for the node, and when they were allowed
+	StakeRplForAllowed []RplPermissionChange `json:"stakeRplForAllowed"`
+
+	// Whether the node's RPL can be locked, e.g. for protocol DAO proposals
+	RplLockingSupported bool `json:"rplLockingSupported"` // False if the contracts don't have RPL locking yet
+	RplLockingAllowed   bool `json:"rplLockingAllowed"`
+
+	// Everyone that has staked RPL for the node, by amount staked
+	Stakers []RplStaker `json:"stakers"`
+
+	// RPL staked before Houston, where the staker wasn't recorded
+	LegacyStakeAmount *big.Int `json:"legacyStakeAmount"`
+
+	// Every permission change, in order
+	History []RplPermissionChange `json:"history"`
+}
+
+// RPLStaked event data
+type rplStakedEvent struct {
+	Node   common.Address
+	From   common.Address
+	Amount *big.Int
+	Time   *big.Int
+}
+
+// StakeRPLForAllowed event data
+type stakeRplForAllowedEvent struct {
+	Node    common.Address
+	Caller  common.Address
+	Allowed bool
+	Time    *big.Int
+}
+
+// RPLLockingAllowed event data
+type rplLockingAllowedEvent struct {
+	Node    common.Address
+	Allowed bool
+	Time    *big.Int
+}
+
+// Get the RPL permissions of a node and everyone that has staked RPL for it, from the events of every rocketNodeStaking deployment.
+// The allow-list is only complete if startBlock is nil or before the node registered.
+func GetRplPermissions(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddress common.Address, startBlock *big.Int, intervalSize *big.Int) (*RplPermissions, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	permissions := &RplPermissions{
+		NodeAddress:        nodeAddress,
+		StakeRplForAllowed: []RplPermissionChange{},
+		Stakers:            []RplStaker{},
+		LegacyStakeAmount:  big.NewInt(0),
+		History:            []RplPermissionChange{},
+	}
+
+	// The RPL withdrawal address and locking setting can be read directly
+	_, permissions.RplLockingSupported = contracts.RocketNodeStaking.ABI.Methods["getRPLLockingAllowed"]
+	if contracts.Version != nil && contracts.Version.GreaterThanOrEqual(houstonVersion) {
+		mc := contracts.Multicaller
+		mc.AddCall(contracts.RocketNodeManager, &permissions.RplWithdrawalAddressIsSet, "getNodeRPLWithdrawalAddressIsSet", nodeAddress)
+		mc.AddCall(contracts.RocketNodeManager, &permissions.RplWithdrawalAddress, "getNodeRPLWithdrawalAddress", nodeAddress)
+		if permissions.RplLockingSupported {
+			mc.AddCall(contracts.RocketNodeStaking, &permissions.RplLockingAllowed, "getRPLLockingAllowed", nodeAddress)
+		}
+		_, err := mc.FlexibleCall(true, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error executing multicall: %w", err)
+		}
+	}
+
+	// Only look for the events the current contract has
+	events := contracts.RocketNodeStaking.ABI.Events
+	eventIDs := []common.Hash{legacyRplStakedEventID}
+	for _, name := range []string{"RPLStaked", "StakeRPLForAllowed", "RPLLockingAllowed"} {
+		if event, exists := events[name]; exists {
+			eventIDs = append(eventIDs, event.ID)
+		}
+	}
+	query := eth.FilterQuery{
+		FromBlock: startBlock,
+		ToBlock:   contracts.ElBlockNumber,
+		Topics:    [][]common.Hash{eventIDs, {common.BytesToHash(nodeAddress.Bytes())}},
+	}
+	logs, err := eth.FilterContractLogs(rp, "rocketNodeStaking", query, intervalSize, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting RPL staking events for node %s: %w", nodeAddress.Hex(), err)
+	}
+
+	if err := permissions.replayLogs(contracts.RocketNodeStaking, logs); err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// Rebuild the permissions and stakers from the node's rocketNodeStaking events, which must be in order
+func (p *RplPermissions) replayLogs(rocketNodeStaking *rocketpool.Contract, logs []types.Log) error {
+	events := rocketNodeStaking.ABI.Events
+	stakers := map[common.Address]*RplStaker{}
+	allowed := map[common.Address]RplPermissionChange{}
+	for _, log := range logs {
+		switch log.Topics[0] {
+		case legacyRplStakedEventID:
+			if len(log.Data) < 32 {
+				return fmt.Errorf("invalid RPL staking event in transaction %s", log.TxHash.Hex())
+			}
+			p.LegacyStakeAmount.Add(p.LegacyStakeAmount, big.NewInt(0).SetBytes(log.Data[:32]))
+
+		case events["RPLStaked"].ID:
+			staked := new(rplStakedEvent)
+			if err := rocketNodeStaking.Contract.UnpackLog(staked, "RPLStaked", log); err != nil {
+				return fmt.Errorf("error unpacking RPL staking event: %w", err)
+			}
+			staker, exists := stakers[staked.From]
+			if !exists {
+				staker = &RplStaker{
+					Address: staked.From,
+					Amount:  big.NewInt(0),
+					IsNode:  staked.From == p.NodeAddress,
+					Allowed: p.isAlwaysAllowed(staked.From),
+				}
+				stakers[staked.From] = staker
+			}
+			staker.Amount.Add(staker.Amount, staked.Amount)
+			staker.StakeCount++
+			staker.LastStake = time.Unix(staked.Time.Int64(), 0)
+
+		case events["StakeRPLForAllowed"].ID:
+			changed := new(stakeRplForAllowedEvent)
+			if err := rocketNodeStaking.Contract.UnpackLog(changed, "StakeRPLForAllowed", log); err != nil {
+				return fmt.Errorf("error unpacking stake RPL for allowed event: %w", err)
+			}
+			change := RplPermissionChange{
+				Setting:     RplPermissionSetting_StakeRplFor,
+				Caller:      changed.Caller,
+				Allowed:     changed.Allowed,
+				Time:        time.Unix(changed.Time.Int64(), 0),
+				BlockNumber: log.BlockNumber,
+				TxHash:      log.TxHash,
+			}
+			p.History = append(p.History, change)
+			allowed[change.Caller] = change
+
+		case events["RPLLockingAllowed"].ID:
+			changed := new(rplLockingAllowedEvent)
+			if err := rocketNodeStaking.Contract.UnpackLog(changed, "RPLLockingAllowed", log); err != nil {
+				return fmt.Errorf("error unpacking RPL locking allowed event: %w", err)
+			}
+			p.History = append(p.History, RplPermissionChange{
+				Setting:     RplPermissionSetting_RplLocking,
+				Allowed:     changed.Allowed,
+				Time:        time.Unix(changed.Time.Int64(), 0),
+				BlockNumber: log.BlockNumber,
+				TxHash:      log.TxHash,
+			})
+		}
+	}
+
+	// Build the allow-list from the latest change for each caller
+	for _, change := range allowed {
+		if change.Allowed {
+			p.StakeRplForAllowed = append(p.StakeRplForAllowed, change)
+		}
+	}
+	sort.Slice(p.StakeRplForAllowed, func(i, j int) bool {
+		return p.StakeRplForAllowed[i].BlockNumber < p.StakeRplForAllowed[j].BlockNumber
+	})
+
+	for address, staker := range stakers {
+		if change, exists := allowed[address]; exists {
+			staker.Allowed = change.Allowed || p.isAlwaysAllowed(address)
+			staker.LastChanged = change.Time
+		}
+		p.Stakers = append(p.Stakers, *staker)
+	}
+	sort.Slice(p.Stakers, func(i, j int) bool {
+		return p.Stakers[i].Amount.Cmp(p.Stakers[j].Amount) > 0
+	})
+	return nil
+}
+
+// Get the addresses currently allowed to stake RPL for the node
+func (p *RplPermissions) GetAllowedCallers() []common.Address {
+	callers := make([]common.Address, len(p.StakeRplForAllowed))
+	for i, change := range p.StakeRplForAllowed {
+		callers[i] = change.Caller
+	}
+	return callers
+}
+
+// Get the address that can change whether the node's RPL can be locked: the RPL withdrawal address if it's set, otherwise the node
+func (p *RplPermissions) GetRplLockingSetter() common.Address {
+	if p.RplWithdrawalAddressIsSet {
+		return p.RplWithdrawalAddress
+	}
+	return p.NodeAddress
+}
+
+// Get the total RPL staked for the node by addresses other than itself
+func (p *RplPermissions) GetStakedOnBehalf() *big.Int {
+	total := big.NewInt(0)
+	for _, staker := range p.Stakers {
+		if !staker.IsNode {
+			total.Add(total, staker.Amount)
+		}
+	}
+	return total
+}
+
+// Estimate the gas of RevokeAll for each allowed caller
+func (p *RplPermissions) EstimateRevokeAllGas(rp *rocketpool.RocketPool, opts *bind.TransactOpts) (map[common.Address]rocketpool.GasInfo, error) {
+	if err := p.checkRevokeAll(opts); err != nil {
+		return nil, err
+	}
+	gasInfos := map[common.Address]rocketpool.GasInfo{}
+	for _, caller := range p.GetAllowedCallers() {
+		gasInfo, err := node.EstimateSetStakeRPLForAllowedGas(rp, caller, false, opts)
+		if err != nil {
+			return nil, err
+		}
+		gasInfos[caller] = gasInfo
+	}
+	return gasInfos, nil
+}
+
+// Revoke the permission of every allowed caller to stake RPL for the node. Must be sent by the node.
+// If opts has a nonce, it's incremented for each transaction. On error, the hashes of the transactions already sent are returned.
+func (p *RplPermissions) RevokeAll(rp *rocketpool.RocketPool, opts *bind.TransactOpts) ([]common.Hash, error) {
+	if err := p.checkRevokeAll(opts); err != nil {
+		return nil, err
+	}
+	callers := p.GetAllowedCallers()
+	return rocketpool.SendTransactions(len(callers), opts, func(i int, txOpts *bind.TransactOpts) (common.Hash, error) {
+		return node.SetStakeRPLForAllowed(rp, callers[i], false, txOpts)
+	})
+}
+
+// Estimate the gas of DisableRplLocking
+func (p *RplPermissions) EstimateDisableRplLockingGas(rp *rocketpool.RocketPool, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := p.checkDisableRplLocking(opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return node.EstimateSetRPLLockingAllowedGas(rp, p.NodeAddress, false, opts)
+}
+
+// Stop the node's RPL from being locked.
+// Must be sent by the node's RPL withdrawal address if it's set, otherwise by the node.
+func (p *RplPermissions) DisableRplLocking(rp *rocketpool.RocketPool, opts *bind.TransactOpts) (common.Hash, error) {
+	if err := p.checkDisableRplLocking(opts); err != nil {
+		return common.Hash{}, err
+	}
+	return node.SetRPLLockingAllowed(rp, p.NodeAddress, false, opts)
+}
+
+// Check if the address can always stake RPL for the node, regardless of the allow-list
+func (p *RplPermissions) isAlwaysAllowed(address common.Address) bool {
+	return address == p.NodeAddress || (p.RplWithdrawalAddressIsSet && address == p.RplWithdrawalAddress)
+}
+
+// Make sure the allow-list can be revoked by the sender of opts
+func (p *RplPermissions) checkRevokeAll(opts *bind.TransactOpts) error {
+	if opts.From != p.NodeAddress {
+		return fmt.Errorf("only node %s can revoke the addresses allowed to stake RPL for it", p.NodeAddress.Hex())
+	}
+	return nil
+}
+
+// Make sure RPL locking can be disabled by the sender of opts
+func (p *RplPermissions) checkDisableRplLocking(opts *bind.TransactOpts) error {
+	if !p.RplLockingSupported {
+		return fmt.Errorf("RPL locking is not supported by the current contracts")
+	}
+	if !p.RplLockingAllowed {
+		return fmt.Errorf("RPL locking is already disabled for node %s", p.NodeAddress.Hex())
+	}
+	if setter := p.GetRplLockingSetter(); opts.From != setter {
+		return fmt.Errorf("only %s can disable RPL locking for node %s", setter.Hex(), p.NodeAddress.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package operator
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// The Houston rocketNodeStaking events
+const rplPermissionsTestAbi = `[
+	{"type":"event","name":"RPLStaked","inputs":[{"name":"node","type":"address","indexed":true},{"name":"from","type":"address","indexed":false},{"name":"amount","type":"uint256","indexed":false},{"name":"time","type":"uint256","indexed":false}]},
+	{"type":"event","name":"StakeRPLForAllowed","inputs":[{"name":"node","type":"address","indexed":true},{"name":"caller","type":"address","indexed":true},{"name":"allowed","type":"bool","indexed":false},{"name":"time","type":"uint256","indexed":false}]},
+	{"type":"event","name":"RPLLockingAllowed","inputs":[{"name":"node","type":"address","indexed":true},{"name":"allowed","type":"bool","indexed":false},{"name":"time","type":"uint256","indexed":false}]}
+]`
+
+func TestRplPermissionsReplayLogs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(rplPermissionsTestAbi))
+	if err != nil {
+		t.Fatalf("error parsing ABI: %v", err)
+	}
+	contractAddress := common.HexToAddress("0x9999999999999999999999999999999999999999")
+	rocketNodeStaking := &rocketpool.Contract{
+		Contract: bind.NewBoundContract(contractAddress, parsed, nil, nil, nil),
+		Address:  &contractAddress,
+		ABI:      &parsed,
+	}
+
+	nodeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	rplWithdrawalAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	callerA := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	callerB := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	start := int64(1700000000)
+
+	// Build a log with the node (and caller) in the topics, in the given block
+	makeLog := func(name string, block uint64, indexed []common.Address, values ...interface{}) types.Log {
+		event := parsed.Events[name]
+		topics := []common.Hash{event.ID, common.BytesToHash(nodeAddress.Bytes())}
+		for _, arg := range indexed {
+			topics = append(topics, common.BytesToHash(arg.Bytes()))
+		}
+		data, err := event.Inputs.NonIndexed().Pack(values...)
+		if err != nil {
+			t.Fatalf("error packing %s: %v", name, err)
+		}
+		return types.Log{Address: contractAddress, Topics: topics, Data: data, BlockNumber: block, TxHash: common.BigToHash(new(big.Int).SetUint64(block))}
+	}
+	staked := func(block uint64, from common.Address, amount int64) types.Log {
+		return makeLog("RPLStaked", block, nil, from, big.NewInt(amount), big.NewInt(start+int64(block)))
+	}
+	allow := func(block uint64, caller common.Address, allowed bool) types.Log {
+		return makeLog("StakeRPLForAllowed", block, []common.Address{caller}, allowed, big.NewInt(start+int64(block)))
+	}
+	locking := func(block uint64, allowed bool) types.Log {
+		return makeLog("RPLLockingAllowed", block, nil, allowed, big.NewInt(start+int64(block)))
+	}
+	legacyStaked := func(block uint64, amount int64) types.Log {
+		data := append(common.BigToHash(big.NewInt(amount)).Bytes(), common.BigToHash(big.NewInt(start)).Bytes()...)
+		return types.Log{Address: contractAddress, Topics: []common.Hash{legacyRplStakedEventID, common.BytesToHash(nodeAddress.Bytes())}, Data: data, BlockNumber: block}
+	}
+	change := func(block uint64, caller common.Address, allowed bool) RplPermissionChange {
+		return RplPermissionChange{
+			Setting:     RplPermissionSetting_StakeRplFor,
+			Caller:      caller,
+			Allowed:     allowed,
+			Time:        time.Unix(start+int64(block), 0),
+			BlockNumber: block,
+			TxHash:      common.BigToHash(new(big.Int).SetUint64(block)),
+		}
+	}
+
+	tests := []struct {
+		name            string
+		logs            []types.Log
+		rplSet          bool
+		expectedAllowed []common.Address
+		expectedStakers []RplStaker
+		expectedLegacy  int64
+		expectedHistory int
+	}{
+		{
+			name:            "no events",
+			expectedAllowed: []common.Address{},
+			expectedStakers: []RplStaker{},
+		},
+		{
+			name:            "legacy stakes",
+			logs:            []types.Log{legacyStaked(1, 100), legacyStaked(2, 50), staked(3, nodeAddress, 10)},
+			expectedAllowed: []common.Address{},
+			expectedStakers: []RplStaker{
+				{Address: nodeAddress, Amount: big.NewInt(10), StakeCount: 1, LastStake: time.Unix(start+3, 0), IsNode: true, Allowed: true},
+			},
+			expectedLegacy: 150,
+		},
+		{
+			name:            "allowed then revoked",
+			logs:            []types.Log{allow(1, callerA, true), staked(2, callerA, 40), allow(3, callerA, false)},
+			expectedAllowed: []common.Address{},
+			expectedStakers: []RplStaker{
+				{Address: callerA, Amount: big.NewInt(40), StakeCount: 1, LastStake: time.Unix(start+2, 0), LastChanged: time.Unix(start+3, 0)},
+			},
+			expectedHistory: 2,
+		},
+		{
+			name:            "revoked then allowed again",
+			logs:            []types.Log{allow(1, callerA, true), allow(2, callerA, false), allow(3, callerA, true), staked(4, callerA, 40), staked(5, callerA, 60)},
+			expectedAllowed: []common.Address{callerA},
+			expectedStakers: []RplStaker{
+				{Address: callerA, Amount: big.NewInt(100), StakeCount: 2, LastStake: time.Unix(start+5, 0), Allowed: true, LastChanged: time.Unix(start+3, 0)},
+			},
+			expectedHistory: 3,
+		},
+		{
+			name:            "allow-list in order of the latest change",
+			logs:            []types.Log{allow(1, callerA, true), allow(2, callerB, true), allow(3, callerA, false), allow(4, callerA, true), locking(5, true)},
+			expectedAllowed: []common.Address{callerB, callerA},
+			expectedStakers: []RplStaker{},
+			expectedHistory: 5,
+		},
+		{
+			name:            "RPL withdrawal address is always allowed",
+			rplSet:          true,
+			logs:            []types.Log{staked(1, rplWithdrawalAddress, 30), allow(2, rplWithdrawalAddress, false), staked(3, callerB, 50)},
+			expectedAllowed: []common.Address{},
+			expectedStakers: []RplStaker{
+				{Address: callerB, Amount: big.NewInt(50), StakeCount: 1, LastStake: time.Unix(start+3, 0)},
+				{Address: rplWithdrawalAddress, Amount: big.NewInt(30), StakeCount: 1, LastStake: time.Unix(start+1, 0), Allowed: true, LastChanged: time.Unix(start+2, 0)},
+			},
+			expectedHistory: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			permissions := &RplPermissions{
+				NodeAddress:        nodeAddress,
+				StakeRplForAllowed: []RplPermissionChange{},
+				Stakers:            []RplStaker{},
+				LegacyStakeAmount:  big.NewInt(0),
+				History:            []RplPermissionChange{},
+			}
+			if test.rplSet {
+				permissions.RplWithdrawalAddressIsSet = true
+				permissions.RplWithdrawalAddress = rplWithdrawalAddress
+			}
+			if err := permissions.replayLogs(rocketNodeStaking, test.logs); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if callers := permissions.GetAllowedCallers(); !reflect.DeepEqual(callers, test.expectedAllowed) {
+				t.Errorf("expected allowed callers %v but got %v", test.expectedAllowed, callers)
+			}
+			if !reflect.DeepEqual(permissions.Stakers, test.expectedStakers) {
+				t.Errorf("expected stakers %+v but got %+v", test.expectedStakers, permissions.Stakers)
+			}
+			if permissions.LegacyStakeAmount.Int64() != test.expectedLegacy {
+				t.Errorf("expected a legacy stake of %d but got %s", test.expectedLegacy, permissions.LegacyStakeAmount)
+			}
+			if len(permissions.History) != test.expectedHistory {
+				t.Errorf("expected %d changes but got %d", test.expectedHistory, len(permissions.History))
+			}
+		})
+	}
+
+	// The history keeps every change in order, and the allow-list keeps the latest allowed change
+	permissions := &RplPermissions{NodeAddress: nodeAddress, LegacyStakeAmount: big.NewInt(0)}
+	if err := permissions.replayLogs(rocketNodeStaking, []types.Log{allow(1, callerA, true), allow(2, callerA, false), allow(3, callerA, true)}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectedHistory := []RplPermissionChange{change(1, callerA, true), change(2, callerA, false), change(3, callerA, true)}
+	if !reflect.DeepEqual(permissions.History, expectedHistory) {
+		t.Errorf("expected history %+v but got %+v", expectedHistory, permissions.History)
+	}
+	if expected := []RplPermissionChange{change(3, callerA, true)}; !reflect.DeepEqual(permissions.StakeRplForAllowed, expected) {
+		t.Errorf("expected allow-list %+v but got %+v", expected, permissions.StakeRplForAllowed)
+	}
+}
+
+func TestRplPermissionsCheckRevokeAll(t *testing.T) {
+	nodeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	rplWithdrawalAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	permissions := &RplPermissions{
+		NodeAddress:               nodeAddress,
+		RplWithdrawalAddressIsSet: true,
+		RplWithdrawalAddress:      rplWithdrawalAddress,
+	}
+
+	if err := permissions.checkRevokeAll(&bind.TransactOpts{From: nodeAddress}); err != nil {
+		t.Errorf("unexpected error for the node: %v", err)
+	}
+	if err := permissions.checkRevokeAll(&bind.TransactOpts{From: rplWithdrawalAddress}); err == nil {
+		t.Error("expected an error for the RPL withdrawal address")
+	}
+}
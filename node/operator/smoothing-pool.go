@@ -0,0 +1,152 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// A node's smoothing pool eligibility for a rewards interval
+type SmoothingPoolEligibility struct {
+	Eligible bool      `json:"eligible"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Fraction float64   `json:"fraction"` // The portion of the interval the node is eligible for
+}
+
+// Whether a node can change its smoothing pool registration, and what changing it would do
+type SmoothingPoolAdvice struct {
+	NodeAddress         common.Address `json:"nodeAddress"`
+	OptedIn             bool           `json:"optedIn"`
+	LastChange          time.Time      `json:"lastChange"`
+	RegistrationEnabled bool           `json:"registrationEnabled"`
+	BlockTime           time.Time      `json:"blockTime"`
+
+	// The current rewards interval; the cooldown between changes is one interval long
+	IntervalStart    time.Time     `json:"intervalStart"`
+	IntervalDuration time.Duration `json:"intervalDuration"`
+
+	// Whether the registration can be changed now
+	ChangeAllowed  bool      `json:"changeAllowed"`
+	NextChangeTime time.Time `json:"nextChangeTime"`
+	Reason         string    `json:"reason"` // Why the change isn't allowed
+
+	// The fee recipient the node's validators must use before and after the change
+	SmoothingPoolAddress    common.Address `json:"smoothingPoolAddress"`
+	DistributorAddress      common.Address `json:"distributorAddress"`
+	FeeRecipient            common.Address `json:"feeRecipient"`
+	FeeRecipientAfterChange common.Address `json:"feeRecipientAfterChange"`
+
+	// Eligibility for the current interval if the node does nothing, and if it changes at NextChangeTime
+	Eligibility            SmoothingPoolEligibility `json:"eligibility"`
+	EligibilityAfterChange SmoothingPoolEligibility `json:"eligibilityAfterChange"`
+}
+
+// Get smoothing pool registration advice for a node
+func GetSmoothingPoolAdvice(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddress common.Address) (*SmoothingPoolAdvice, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	advice := &SmoothingPoolAdvice{
+		NodeAddress:          nodeAddress,
+		SmoothingPoolAddress: *contracts.RocketSmoothingPool.Address,
+	}
+
+	var lastChange *big.Int
+	var intervalStart *big.Int
+	var intervalDuration *big.Int
+	mc := contracts.Multicaller
+	mc.AddCall(contracts.RocketNodeManager, &advice.OptedIn, "getSmoothingPoolRegistrationState", nodeAddress)
+	mc.AddCall(contracts.RocketNodeManager, &lastChange, "getSmoothingPoolRegistrationChanged", nodeAddress)
+	mc.AddCall(contracts.RocketDAOProtocolSettingsNode, &advice.RegistrationEnabled, "getSmoothingPoolRegistrationEnabled")
+	mc.AddCall(contracts.RocketRewardsPool, &intervalStart, "getClaimIntervalTimeStart")
+	mc.AddCall(contracts.RocketRewardsPool, &intervalDuration, "getClaimIntervalTime")
+	mc.AddCall(contracts.RocketNodeDistributorFactory, &advice.DistributorAddress, "getProxyAddress", nodeAddress)
+	_, err := mc.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	header, err := rp.Client.HeaderByNumber(context.Background(), contracts.ElBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("error getting header for block %s: %w", contracts.ElBlockNumber.String(), err)
+	}
+
+	advice.LastChange = time.Unix(lastChange.Int64(), 0)
+	advice.BlockTime = time.Unix(int64(header.Time), 0)
+	advice.IntervalStart = time.Unix(intervalStart.Int64(), 0)
+	advice.IntervalDuration = time.Duration(intervalDuration.Uint64()) * time.Second
+
+	advice.calculateChange()
+	return advice, nil
+}
+
+// Work out whether and when the registration can be changed, and what the change would do
+func (a *SmoothingPoolAdvice) calculateChange() {
+	// The contract only allows one change per rewards interval duration
+	a.NextChangeTime = a.BlockTime
+	if a.LastChange.Unix() > 0 {
+		cooldownEnd := a.LastChange.Add(a.IntervalDuration)
+		if cooldownEnd.After(a.NextChangeTime) {
+			a.NextChangeTime = cooldownEnd
+		}
+	}
+	switch {
+	case !a.RegistrationEnabled:
+		a.Reason = "smoothing pool registration changes are currently disabled"
+	case a.NextChangeTime.After(a.BlockTime):
+		a.Reason = fmt.Sprintf("the registration was changed less than one rewards interval ago; the next change is allowed at %s", a.NextChangeTime.UTC().Format(time.RFC3339))
+	default:
+		a.ChangeAllowed = true
+	}
+
+	// Fee recipients
+	if a.OptedIn {
+		a.FeeRecipient = a.SmoothingPoolAddress
+		a.FeeRecipientAfterChange = a.DistributorAddress
+	} else {
+		a.FeeRecipient = a.DistributorAddress
+		a.FeeRecipientAfterChange = a.SmoothingPoolAddress
+	}
+
+	// Eligibility
+	a.Eligibility = a.getEligibility(a.OptedIn, a.LastChange)
+	a.EligibilityAfterChange = a.GetEligibilityAfterChangeAt(a.NextChangeTime)
+}
+
+// Get the end of the current rewards interval
+func (a *SmoothingPoolAdvice) GetIntervalEnd() time.Time {
+	return a.IntervalStart.Add(a.IntervalDuration)
+}
+
+// Get the node's eligibility for the current interval if it changes its registration at the given time
+func (a *SmoothingPoolAdvice) GetEligibilityAfterChangeAt(t time.Time) SmoothingPoolEligibility {
+	return a.getEligibility(!a.OptedIn, t)
+}
+
+// Get the eligibility for the current interval of a node with the given registration and time of its last change
+func (a *SmoothingPoolAdvice) getEligibility(optedIn bool, changed time.Time) SmoothingPoolEligibility {
+	node := state.NativeNodeDetails{
+		SmoothingPoolRegistrationState:   optedIn,
+		SmoothingPoolRegistrationChanged: big.NewInt(changed.Unix()),
+	}
+	eligible, start, end := node.IsEligibleForBonuses(a.IntervalStart, a.GetIntervalEnd())
+	if !eligible || !end.After(start) {
+		return SmoothingPoolEligibility{}
+	}
+
+	eligibility := SmoothingPoolEligibility{
+		Eligible: true,
+		Start:    start,
+		End:      end,
+	}
+	if a.IntervalDuration > 0 {
+		eligibility.Fraction = float64(end.Sub(start)) / float64(a.IntervalDuration)
+	}
+	return eligibility
+}
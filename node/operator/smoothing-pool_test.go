@@ -0,0 +1,127 @@
+package operator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSmoothingPoolAdviceCalculateChange(t *testing.T) {
+	day := 24 * time.Hour
+	intervalStart := time.Unix(1700000000, 0)
+	blockTime := intervalStart.Add(2 * day)
+	intervalEnd := intervalStart.Add(10 * day)
+	smoothingPool := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	distributor := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	tests := []struct {
+		name                 string
+		optedIn              bool
+		lastChange           time.Time
+		disabled             bool
+		expectedAllowed      bool
+		expectedReason       string
+		expectedNextChange   time.Time
+		expectedFeeRecipient common.Address
+		expectedEligibility  SmoothingPoolEligibility
+		expectedAfterChange  SmoothingPoolEligibility
+	}{
+		{
+			name:                 "never registered",
+			lastChange:           time.Unix(0, 0),
+			expectedAllowed:      true,
+			expectedNextChange:   blockTime,
+			expectedFeeRecipient: distributor,
+			expectedAfterChange:  SmoothingPoolEligibility{Eligible: true, Start: blockTime, End: intervalEnd, Fraction: 0.8},
+		},
+		{
+			name:                 "opted in before the interval",
+			optedIn:              true,
+			lastChange:           intervalStart.Add(-20 * day),
+			expectedAllowed:      true,
+			expectedNextChange:   blockTime,
+			expectedFeeRecipient: smoothingPool,
+			expectedEligibility:  SmoothingPoolEligibility{Eligible: true, Start: intervalStart, End: intervalEnd, Fraction: 1},
+		},
+		{
+			name:                 "opted in during the interval",
+			optedIn:              true,
+			lastChange:           intervalStart.Add(day),
+			expectedReason:       "the registration was changed less than one rewards interval ago",
+			expectedNextChange:   intervalStart.Add(11 * day),
+			expectedFeeRecipient: smoothingPool,
+			expectedEligibility:  SmoothingPoolEligibility{Eligible: true, Start: intervalStart.Add(day), End: intervalEnd, Fraction: 0.9},
+		},
+		{
+			name:                 "opted out in the cooldown",
+			lastChange:           intervalStart.Add(-5 * day),
+			expectedReason:       "the registration was changed less than one rewards interval ago",
+			expectedNextChange:   intervalStart.Add(5 * day),
+			expectedFeeRecipient: distributor,
+			expectedAfterChange:  SmoothingPoolEligibility{Eligible: true, Start: intervalStart.Add(5 * day), End: intervalEnd, Fraction: 0.5},
+		},
+		{
+			name:                 "opted out after the cooldown",
+			lastChange:           blockTime.Add(-10 * day),
+			expectedAllowed:      true,
+			expectedNextChange:   blockTime,
+			expectedFeeRecipient: distributor,
+			expectedAfterChange:  SmoothingPoolEligibility{Eligible: true, Start: blockTime, End: intervalEnd, Fraction: 0.8},
+		},
+		{
+			name:                 "registration disabled",
+			lastChange:           time.Unix(0, 0),
+			disabled:             true,
+			expectedReason:       "smoothing pool registration changes are currently disabled",
+			expectedNextChange:   blockTime,
+			expectedFeeRecipient: distributor,
+			expectedAfterChange:  SmoothingPoolEligibility{Eligible: true, Start: blockTime, End: intervalEnd, Fraction: 0.8},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			advice := &SmoothingPoolAdvice{
+				OptedIn:              test.optedIn,
+				LastChange:           test.lastChange,
+				RegistrationEnabled:  !test.disabled,
+				BlockTime:            blockTime,
+				IntervalStart:        intervalStart,
+				IntervalDuration:     10 * day,
+				SmoothingPoolAddress: smoothingPool,
+				DistributorAddress:   distributor,
+			}
+			advice.calculateChange()
+
+			if advice.ChangeAllowed != test.expectedAllowed {
+				t.Errorf("expected change allowed %t but got %t (%s)", test.expectedAllowed, advice.ChangeAllowed, advice.Reason)
+			}
+			if !strings.HasPrefix(advice.Reason, test.expectedReason) || (test.expectedReason == "" && advice.Reason != "") {
+				t.Errorf("expected reason %q but got %q", test.expectedReason, advice.Reason)
+			}
+			if !advice.NextChangeTime.Equal(test.expectedNextChange) {
+				t.Errorf("expected next change at %s but got %s", test.expectedNextChange, advice.NextChangeTime)
+			}
+			if advice.FeeRecipient != test.expectedFeeRecipient {
+				t.Errorf("expected fee recipient %s but got %s", test.expectedFeeRecipient.Hex(), advice.FeeRecipient.Hex())
+			}
+			if advice.FeeRecipient == advice.FeeRecipientAfterChange {
+				t.Errorf("fee recipient after the change should differ from %s", advice.FeeRecipient.Hex())
+			}
+			checkEligibility(t, "current", test.expectedEligibility, advice.Eligibility)
+			checkEligibility(t, "after change", test.expectedAfterChange, advice.EligibilityAfterChange)
+		})
+	}
+}
+
+func checkEligibility(t *testing.T, name string, expected SmoothingPoolEligibility, actual SmoothingPoolEligibility) {
+	t.Helper()
+	if actual.Eligible != expected.Eligible ||
+		!actual.Start.Equal(expected.Start) ||
+		!actual.End.Equal(expected.End) ||
+		actual.Fraction != expected.Fraction {
+		t.Errorf("expected %s eligibility %+v but got %+v", name, expected, actual)
+	}
+}
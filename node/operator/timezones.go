@@ -0,0 +1,201 @@
+package operator
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+	rpstrings "github.com/rocket-pool/rocketpool-go/utils/strings"
+	"golang.org/x/sync/errgroup"
+)
+
+// Settings
+const (
+	timezoneBatchSize   int = 1000
+	timezoneThreadLimit int = 6
+)
+
+// Groups for timezones that can't be placed
+const (
+	TimezoneGroup_Invalid string = "Invalid"
+	TimezoneGroup_Unknown string = "Unknown"
+)
+
+// Maps a timezone location to the group it belongs to, such as its country or region
+type TimezoneGroupFunc func(timezoneLocation string) string
+
+// The timezone of a node
+type NodeTimezone struct {
+	NodeAddress      common.Address `json:"nodeAddress"`
+	TimezoneLocation string         `json:"timezoneLocation"`
+	Valid            bool           `json:"valid"` // Whether the location is in the IANA database
+}
+
+// The nodes in a group of timezones
+type TimezoneGroup struct {
+	Name      string            `json:"name"`
+	NodeCount uint64            `json:"nodeCount"`
+	Share     float64           `json:"share"` // The fraction of all nodes in the group
+	Timezones map[string]uint64 `json:"timezones"`
+}
+
+// Get the timezones of the given nodes, or of every node if nodeAddresses is nil
+func GetNodeTimezones(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddresses []common.Address) ([]NodeTimezone, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	if nodeAddresses == nil {
+		addresses, err := node.GetNodeAddressesFast(rp, contracts.Multicaller.ContractAddress, opts)
+		if err != nil {
+			return nil, err
+		}
+		nodeAddresses = addresses
+	}
+
+	count := len(nodeAddresses)
+	timezones := make([]NodeTimezone, count)
+	var wg errgroup.Group
+	wg.SetLimit(timezoneThreadLimit)
+	for i := 0; i < count; i += timezoneBatchSize {
+		i := i
+		max := i + timezoneBatchSize
+		if max > count {
+			max = count
+		}
+
+		wg.Go(func() error {
+			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
+			if err != nil {
+				return err
+			}
+			for j := i; j < max; j++ {
+				timezones[j].NodeAddress = nodeAddresses[j]
+				mc.AddCall(contracts.RocketNodeManager, &timezones[j].TimezoneLocation, "getNodeTimezoneLocation", nodeAddresses[j])
+			}
+			_, err = mc.FlexibleCall(true, opts)
+			if err != nil {
+				return fmt.Errorf("error executing multicall: %w", err)
+			}
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting node timezones: %w", err)
+	}
+
+	for i := range timezones {
+		timezone := &timezones[i]
+		timezone.TimezoneLocation = rpstrings.Sanitize(timezone.TimezoneLocation)
+		timezone.Valid = node.ValidateTimezoneLocation(timezone.TimezoneLocation) == nil
+	}
+	return timezones, nil
+}
+
+// Group the nodes by their timezones, largest group first
+func AggregateNodeTimezones(timezones []NodeTimezone, groupFunc TimezoneGroupFunc) []TimezoneGroup {
+	counts := make([]node.TimezoneCount, 0, len(timezones))
+	for _, timezone := range timezones {
+		counts = append(counts, node.TimezoneCount{Timezone: timezone.TimezoneLocation})
+	}
+	return aggregateTimezones(counts, groupFunc, func(node.TimezoneCount) uint64 { return 1 })
+}
+
+// Group the counts from node.GetAllNodeCountPerTimezone, largest group first
+func AggregateTimezoneCounts(counts []node.TimezoneCount, groupFunc TimezoneGroupFunc) []TimezoneGroup {
+	return aggregateTimezones(counts, groupFunc, func(count node.TimezoneCount) uint64 { return count.Count.Uint64() })
+}
+
+// Group timezones by region, the part of the location before the first slash (e.g. "Europe" for "Europe/Berlin")
+func GroupByRegion(timezoneLocation string) string {
+	if node.ValidateTimezoneLocation(timezoneLocation) != nil {
+		return TimezoneGroup_Invalid
+	}
+	region, _, found := strings.Cut(timezoneLocation, "/")
+	if !found {
+		// Names like UTC don't have a region
+		return TimezoneGroup_Unknown
+	}
+	return region
+}
+
+// Group timezones by country, using a map of timezone locations to country codes (see ParseZoneTab).
+// Go's copy of the IANA database doesn't include country information, so it has to be provided.
+func GroupByCountry(countries map[string]string) TimezoneGroupFunc {
+	return func(timezoneLocation string) string {
+		if node.ValidateTimezoneLocation(timezoneLocation) != nil {
+			return TimezoneGroup_Invalid
+		}
+		country, exists := countries[timezoneLocation]
+		if !exists {
+			return TimezoneGroup_Unknown
+		}
+		return country
+	}
+}
+
+// Parse a zone.tab or zone1970.tab file into a map of timezone locations to country codes.
+// Locations shared by several countries in zone1970.tab are mapped to the first one.
+func ParseZoneTab(reader io.Reader) (map[string]string, error) {
+	countries := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid zone table line '%s'", line)
+		}
+		country, _, _ := strings.Cut(fields[0], ",")
+		countries[fields[2]] = country
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading zone table: %w", err)
+	}
+	return countries, nil
+}
+
+// Group timezone counts, largest group first
+func aggregateTimezones(counts []node.TimezoneCount, groupFunc TimezoneGroupFunc, getCount func(node.TimezoneCount) uint64) []TimezoneGroup {
+	groups := map[string]*TimezoneGroup{}
+	total := uint64(0)
+	for _, count := range counts {
+		nodeCount := getCount(count)
+		name := groupFunc(count.Timezone)
+		group, exists := groups[name]
+		if !exists {
+			group = &TimezoneGroup{
+				Name:      name,
+				Timezones: map[string]uint64{},
+			}
+			groups[name] = group
+		}
+		group.NodeCount += nodeCount
+		group.Timezones[count.Timezone] += nodeCount
+		total += nodeCount
+	}
+
+	results := make([]TimezoneGroup, 0, len(groups))
+	for _, group := range groups {
+		if total > 0 {
+			group.Share = float64(group.NodeCount) / float64(total)
+		}
+		results = append(results, *group)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].NodeCount != results[j].NodeCount {
+			return results[i].NodeCount > results[j].NodeCount
+		}
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
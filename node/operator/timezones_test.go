@@ -0,0 +1,147 @@
+package operator
+
+import (
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rocket-pool/rocketpool-go/node"
+)
+
+func TestAggregateTimezones(t *testing.T) {
+	countries := map[string]string{
+		"Europe/Berlin":    "DE",
+		"Europe/Busingen":  "DE",
+		"America/New_York": "US",
+	}
+	tests := []struct {
+		name      string
+		counts    []node.TimezoneCount
+		groupFunc TimezoneGroupFunc
+		expected  []TimezoneGroup
+	}{
+		{
+			name:      "empty",
+			counts:    []node.TimezoneCount{},
+			groupFunc: GroupByRegion,
+			expected:  []TimezoneGroup{},
+		},
+		{
+			name: "by region",
+			counts: []node.TimezoneCount{
+				{Timezone: "Europe/Berlin", Count: big.NewInt(3)},
+				{Timezone: "America/New_York", Count: big.NewInt(4)},
+				{Timezone: "Europe/London", Count: big.NewInt(2)},
+				{Timezone: "UTC", Count: big.NewInt(1)},
+			},
+			groupFunc: GroupByRegion,
+			expected: []TimezoneGroup{
+				{Name: "Europe", NodeCount: 5, Share: 0.5, Timezones: map[string]uint64{"Europe/Berlin": 3, "Europe/London": 2}},
+				{Name: "America", NodeCount: 4, Share: 0.4, Timezones: map[string]uint64{"America/New_York": 4}},
+				{Name: TimezoneGroup_Unknown, NodeCount: 1, Share: 0.1, Timezones: map[string]uint64{"UTC": 1}},
+			},
+		},
+		{
+			name: "invalid locations",
+			counts: []node.TimezoneCount{
+				{Timezone: "Europe/Berlin", Count: big.NewInt(2)},
+				{Timezone: "Mars/Olympus_Mons", Count: big.NewInt(1)},
+				{Timezone: "posix/Europe/Berlin", Count: big.NewInt(1)},
+				{Timezone: "", Count: big.NewInt(0)},
+			},
+			groupFunc: GroupByRegion,
+			expected: []TimezoneGroup{
+				{Name: "Europe", NodeCount: 2, Share: 0.5, Timezones: map[string]uint64{"Europe/Berlin": 2}},
+				{Name: TimezoneGroup_Invalid, NodeCount: 2, Share: 0.5, Timezones: map[string]uint64{"Mars/Olympus_Mons": 1, "posix/Europe/Berlin": 1, "": 0}},
+			},
+		},
+		{
+			name: "ties sorted by name",
+			counts: []node.TimezoneCount{
+				{Timezone: "Europe/Busingen", Count: big.NewInt(1)},
+				{Timezone: "America/New_York", Count: big.NewInt(2)},
+				{Timezone: "Europe/Berlin", Count: big.NewInt(1)},
+				{Timezone: "Asia/Tokyo", Count: big.NewInt(2)},
+			},
+			groupFunc: GroupByCountry(countries),
+			expected: []TimezoneGroup{
+				{Name: "DE", NodeCount: 2, Share: 1.0 / 3, Timezones: map[string]uint64{"Europe/Berlin": 1, "Europe/Busingen": 1}},
+				{Name: "US", NodeCount: 2, Share: 1.0 / 3, Timezones: map[string]uint64{"America/New_York": 2}},
+				{Name: TimezoneGroup_Unknown, NodeCount: 2, Share: 1.0 / 3, Timezones: map[string]uint64{"Asia/Tokyo": 2}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			groups := AggregateTimezoneCounts(test.counts, test.groupFunc)
+			if !reflect.DeepEqual(groups, test.expected) {
+				t.Errorf("unexpected groups:\nwant %+v\ngot  %+v", test.expected, groups)
+			}
+		})
+	}
+}
+
+func TestParseZoneTab(t *testing.T) {
+	tests := []struct {
+		name     string
+		table    string
+		expected map[string]string
+		err      string
+	}{
+		{
+			name: "zone.tab",
+			table: "# tzdb timezone descriptions\n" +
+				"#\n" +
+				"DE\t+5230+01322\tEurope/Berlin\tmost of Germany\n" +
+				"DE\t+4742+00841\tEurope/Busingen\tBusingen\n" +
+				"US\t+404251-0740023\tAmerica/New_York\tEastern (most areas)\n",
+			expected: map[string]string{
+				"Europe/Berlin":    "DE",
+				"Europe/Busingen":  "DE",
+				"America/New_York": "US",
+			},
+		},
+		{
+			name: "zone1970.tab with shared locations",
+			table: "#country-\n" +
+				"#codes\tcoordinates\tTZ\tcomments\n" +
+				"\n" +
+				"CH,DE,LI\t+4723+00832\tEurope/Zurich\tBüsingen\n" +
+				"JP\t+353916+1394441\tAsia/Tokyo\n",
+			expected: map[string]string{
+				"Europe/Zurich": "CH",
+				"Asia/Tokyo":    "JP",
+			},
+		},
+		{
+			name:     "empty",
+			table:    "",
+			expected: map[string]string{},
+		},
+		{
+			name:  "missing the location",
+			table: "DE\t+5230+01322\n",
+			err:   "invalid zone table line",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			countries, err := ParseZoneTab(strings.NewReader(test.table))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing '%s', got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(countries, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, countries)
+			}
+		})
+	}
+}
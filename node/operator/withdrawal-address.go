@@ -0,0 +1,331 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/node"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/storage"
+	"github.com/rocket-pool/rocketpool-go/utils/state"
+)
+
+// A withdrawal address setting of a node
+type WithdrawalAddressSetting string
+
+const (
+	WithdrawalAddressSetting_Primary WithdrawalAddressSetting = "primary"
+	WithdrawalAddressSetting_Rpl     WithdrawalAddressSetting = "rpl"
+)
+
+// An action on a withdrawal address setting
+type WithdrawalAddressAction string
+
+const (
+	WithdrawalAddressAction_Set     WithdrawalAddressAction = "set"
+	WithdrawalAddressAction_Confirm WithdrawalAddressAction = "confirm"
+)
+
+// The address that can currently perform an action on a withdrawal address setting
+type WithdrawalAddressPermission struct {
+	Setting WithdrawalAddressSetting `json:"setting"`
+	Action  WithdrawalAddressAction  `json:"action"`
+	Address common.Address           `json:"address"`
+}
+
+// The result of checking a new withdrawal address before setting it
+type WithdrawalAddressCheck struct {
+	Setting       WithdrawalAddressSetting `json:"setting"`
+	Address       common.Address           `json:"address"`
+	Sender        common.Address           `json:"sender"`
+	AllowedSender common.Address           `json:"allowedSender"`
+	IsContract    bool                     `json:"isContract"`
+	Errors        []string                 `json:"errors"`   // Problems that stop the change
+	Warnings      []string                 `json:"warnings"` // Things to double check before making the change
+}
+
+// Manages the withdrawal addresses of a node.
+// Changes always go through the two-step flow, where the new address has to confirm itself, unless one of the explicit Immediately functions is used.
+type WithdrawalAddressManager struct {
+	NodeAddress  common.Address `json:"nodeAddress"`
+	HoustonRules bool           `json:"houstonRules"` // RPL withdrawal addresses only exist under Houston
+
+	WithdrawalAddress           common.Address `json:"withdrawalAddress"`
+	PendingWithdrawalAddress    common.Address `json:"pendingWithdrawalAddress"`
+	RplWithdrawalAddressIsSet   bool           `json:"rplWithdrawalAddressIsSet"`
+	RplWithdrawalAddress        common.Address `json:"rplWithdrawalAddress"`
+	PendingRplWithdrawalAddress common.Address `json:"pendingRplWithdrawalAddress"`
+
+	rp          *rocketpool.RocketPool
+	blockNumber *big.Int
+}
+
+// Load the withdrawal addresses of a node
+func NewWithdrawalAddressManager(rp *rocketpool.RocketPool, contracts *state.NetworkContracts, nodeAddress common.Address) (*WithdrawalAddressManager, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	manager := &WithdrawalAddressManager{
+		NodeAddress:  nodeAddress,
+		HoustonRules: contracts.Version != nil && contracts.Version.GreaterThanOrEqual(houstonVersion),
+		rp:           rp,
+		blockNumber:  contracts.ElBlockNumber,
+	}
+
+	mc := contracts.Multicaller
+	mc.AddCall(contracts.RocketStorage, &manager.WithdrawalAddress, "getNodeWithdrawalAddress", nodeAddress)
+	mc.AddCall(contracts.RocketStorage, &manager.PendingWithdrawalAddress, "getNodePendingWithdrawalAddress", nodeAddress)
+	if manager.HoustonRules {
+		mc.AddCall(contracts.RocketNodeManager, &manager.RplWithdrawalAddressIsSet, "getNodeRPLWithdrawalAddressIsSet", nodeAddress)
+		mc.AddCall(contracts.RocketNodeManager, &manager.RplWithdrawalAddress, "getNodeRPLWithdrawalAddress", nodeAddress)
+		mc.AddCall(contracts.RocketNodeManager, &manager.PendingRplWithdrawalAddress, "getNodePendingRPLWithdrawalAddress", nodeAddress)
+	}
+	_, err := mc.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	return manager, nil
+}
+
+// Check if the node has a withdrawal address change waiting for confirmation
+func (m *WithdrawalAddressManager) HasPendingWithdrawalAddress() bool {
+	return m.PendingWithdrawalAddress != (common.Address{})
+}
+
+// Check if the node has an RPL withdrawal address change waiting for confirmation
+func (m *WithdrawalAddressManager) HasPendingRplWithdrawalAddress() bool {
+	return m.PendingRplWithdrawalAddress != (common.Address{})
+}
+
+// Get the address RPL rewards and withdrawals go to
+func (m *WithdrawalAddressManager) GetEffectiveRplWithdrawalAddress() common.Address {
+	if m.RplWithdrawalAddressIsSet {
+		return m.RplWithdrawalAddress
+	}
+	return m.WithdrawalAddress
+}
+
+// Get the address allowed to set a withdrawal address setting
+func (m *WithdrawalAddressManager) GetSetter(setting WithdrawalAddressSetting) common.Address {
+	if setting == WithdrawalAddressSetting_Rpl {
+		// Once set, only the RPL withdrawal address can change itself
+		return m.GetEffectiveRplWithdrawalAddress()
+	}
+	return m.WithdrawalAddress
+}
+
+// Get the addresses that can currently change each withdrawal address setting
+func (m *WithdrawalAddressManager) GetPermissions() []WithdrawalAddressPermission {
+	permissions := []WithdrawalAddressPermission{
+		{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Set, Address: m.GetSetter(WithdrawalAddressSetting_Primary)},
+	}
+	if m.HasPendingWithdrawalAddress() {
+		permissions = append(permissions, WithdrawalAddressPermission{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Confirm, Address: m.PendingWithdrawalAddress})
+	}
+	if !m.HoustonRules {
+		return permissions
+	}
+	permissions = append(permissions, WithdrawalAddressPermission{Setting: WithdrawalAddressSetting_Rpl, Action: WithdrawalAddressAction_Set, Address: m.GetSetter(WithdrawalAddressSetting_Rpl)})
+	if m.HasPendingRplWithdrawalAddress() {
+		permissions = append(permissions, WithdrawalAddressPermission{Setting: WithdrawalAddressSetting_Rpl, Action: WithdrawalAddressAction_Confirm, Address: m.PendingRplWithdrawalAddress})
+	}
+	return permissions
+}
+
+// Check if an address is a contract
+func (m *WithdrawalAddressManager) IsContract(address common.Address) (bool, error) {
+	code, err := m.rp.Client.CodeAt(context.Background(), address, m.blockNumber)
+	if err != nil {
+		return false, fmt.Errorf("error getting code for address %s: %w", address.Hex(), err)
+	}
+	return len(code) > 0, nil
+}
+
+// Check a new withdrawal address before it's set by the sender
+func (m *WithdrawalAddressManager) CheckWithdrawalAddress(setting WithdrawalAddressSetting, address common.Address, sender common.Address) (*WithdrawalAddressCheck, error) {
+	check := &WithdrawalAddressCheck{
+		Setting:       setting,
+		Address:       address,
+		Sender:        sender,
+		AllowedSender: m.GetSetter(setting),
+		Errors:        []string{},
+		Warnings:      []string{},
+	}
+
+	var current, pending common.Address
+	switch setting {
+	case WithdrawalAddressSetting_Primary:
+		current, pending = m.WithdrawalAddress, m.PendingWithdrawalAddress
+	case WithdrawalAddressSetting_Rpl:
+		if !m.HoustonRules {
+			return nil, fmt.Errorf("RPL withdrawal addresses are not supported before Houston")
+		}
+		current, pending = m.RplWithdrawalAddress, m.PendingRplWithdrawalAddress
+	default:
+		return nil, fmt.Errorf("unknown withdrawal address setting '%s'", setting)
+	}
+
+	if address == (common.Address{}) {
+		check.Errors = append(check.Errors, "the new address is the zero address")
+	}
+	if sender != check.AllowedSender {
+		check.Errors = append(check.Errors, fmt.Sprintf("only %s can set the %s withdrawal address", check.AllowedSender.Hex(), setting))
+	}
+	if address == current {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("the new address is already the %s withdrawal address", setting))
+	}
+	if pending != (common.Address{}) && address != pending {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("this will replace the pending %s withdrawal address %s", setting, pending.Hex()))
+	}
+	if address == m.NodeAddress {
+		check.Warnings = append(check.Warnings, "the new address is the node address, which keeps its key on the node machine")
+	}
+
+	isContract, err := m.IsContract(address)
+	if err != nil {
+		return nil, err
+	}
+	check.IsContract = isContract
+	if isContract {
+		check.Warnings = append(check.Warnings, "the new address is a contract; make sure it can confirm the change and manage the node")
+	}
+	return check, nil
+}
+
+// Check if the new withdrawal address can be set
+func (c *WithdrawalAddressCheck) CanSet() bool {
+	return len(c.Errors) == 0
+}
+
+// Estimate the gas of SetWithdrawalAddress
+func (m *WithdrawalAddressManager) EstimateSetWithdrawalAddressGas(address common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Primary, address, opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return storage.EstimateSetWithdrawalAddressGas(m.rp, m.NodeAddress, address, false, opts)
+}
+
+// Propose a new withdrawal address; it becomes active once the new address confirms it
+func (m *WithdrawalAddressManager) SetWithdrawalAddress(address common.Address, opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Primary, address, opts); err != nil {
+		return common.Hash{}, err
+	}
+	return storage.SetWithdrawalAddress(m.rp, m.NodeAddress, address, false, opts)
+}
+
+// Estimate the gas of SetWithdrawalAddressImmediately
+func (m *WithdrawalAddressManager) EstimateSetWithdrawalAddressImmediatelyGas(address common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Primary, address, opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return storage.EstimateSetWithdrawalAddressGas(m.rp, m.NodeAddress, address, true, opts)
+}
+
+// Set a new withdrawal address without waiting for it to confirm.
+// This can't be undone if the address is wrong; prefer SetWithdrawalAddress.
+func (m *WithdrawalAddressManager) SetWithdrawalAddressImmediately(address common.Address, opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Primary, address, opts); err != nil {
+		return common.Hash{}, err
+	}
+	return storage.SetWithdrawalAddress(m.rp, m.NodeAddress, address, true, opts)
+}
+
+// Estimate the gas of ConfirmWithdrawalAddress
+func (m *WithdrawalAddressManager) EstimateConfirmWithdrawalAddressGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkConfirm(WithdrawalAddressSetting_Primary, opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return storage.EstimateConfirmWithdrawalAddressGas(m.rp, m.NodeAddress, opts)
+}
+
+// Confirm the pending withdrawal address; must be sent from the pending address
+func (m *WithdrawalAddressManager) ConfirmWithdrawalAddress(opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkConfirm(WithdrawalAddressSetting_Primary, opts); err != nil {
+		return common.Hash{}, err
+	}
+	return storage.ConfirmWithdrawalAddress(m.rp, m.NodeAddress, opts)
+}
+
+// Estimate the gas of SetRplWithdrawalAddress
+func (m *WithdrawalAddressManager) EstimateSetRplWithdrawalAddressGas(address common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Rpl, address, opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return node.EstimateSetRPLWithdrawalAddressGas(m.rp, m.NodeAddress, address, false, opts)
+}
+
+// Propose a new RPL withdrawal address; it becomes active once the new address confirms it
+func (m *WithdrawalAddressManager) SetRplWithdrawalAddress(address common.Address, opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Rpl, address, opts); err != nil {
+		return common.Hash{}, err
+	}
+	return node.SetRPLWithdrawalAddress(m.rp, m.NodeAddress, address, false, opts)
+}
+
+// Estimate the gas of SetRplWithdrawalAddressImmediately
+func (m *WithdrawalAddressManager) EstimateSetRplWithdrawalAddressImmediatelyGas(address common.Address, opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Rpl, address, opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return node.EstimateSetRPLWithdrawalAddressGas(m.rp, m.NodeAddress, address, true, opts)
+}
+
+// Set a new RPL withdrawal address without waiting for it to confirm.
+// This can't be undone if the address is wrong; prefer SetRplWithdrawalAddress.
+func (m *WithdrawalAddressManager) SetRplWithdrawalAddressImmediately(address common.Address, opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkSet(WithdrawalAddressSetting_Rpl, address, opts); err != nil {
+		return common.Hash{}, err
+	}
+	return node.SetRPLWithdrawalAddress(m.rp, m.NodeAddress, address, true, opts)
+}
+
+// Estimate the gas of ConfirmRplWithdrawalAddress
+func (m *WithdrawalAddressManager) EstimateConfirmRplWithdrawalAddressGas(opts *bind.TransactOpts) (rocketpool.GasInfo, error) {
+	if err := m.checkConfirm(WithdrawalAddressSetting_Rpl, opts); err != nil {
+		return rocketpool.GasInfo{}, err
+	}
+	return node.EstimateConfirmRPLWithdrawalAddressGas(m.rp, m.NodeAddress, opts)
+}
+
+// Confirm the pending RPL withdrawal address; must be sent from the pending address
+func (m *WithdrawalAddressManager) ConfirmRplWithdrawalAddress(opts *bind.TransactOpts) (common.Hash, error) {
+	if err := m.checkConfirm(WithdrawalAddressSetting_Rpl, opts); err != nil {
+		return common.Hash{}, err
+	}
+	return node.ConfirmRPLWithdrawalAddress(m.rp, m.NodeAddress, opts)
+}
+
+// Make sure a withdrawal address can be set by the sender of opts
+func (m *WithdrawalAddressManager) checkSet(setting WithdrawalAddressSetting, address common.Address, opts *bind.TransactOpts) error {
+	if setting == WithdrawalAddressSetting_Rpl && !m.HoustonRules {
+		return fmt.Errorf("RPL withdrawal addresses are not supported before Houston")
+	}
+	if address == (common.Address{}) {
+		return fmt.Errorf("cannot set the %s withdrawal address to the zero address", setting)
+	}
+	if setter := m.GetSetter(setting); opts.From != setter {
+		return fmt.Errorf("only %s can set the %s withdrawal address of node %s", setter.Hex(), setting, m.NodeAddress.Hex())
+	}
+	return nil
+}
+
+// Make sure a pending withdrawal address can be confirmed by the sender of opts
+func (m *WithdrawalAddressManager) checkConfirm(setting WithdrawalAddressSetting, opts *bind.TransactOpts) error {
+	pending := m.PendingWithdrawalAddress
+	if setting == WithdrawalAddressSetting_Rpl {
+		if !m.HoustonRules {
+			return fmt.Errorf("RPL withdrawal addresses are not supported before Houston")
+		}
+		pending = m.PendingRplWithdrawalAddress
+	}
+	if pending == (common.Address{}) {
+		return fmt.Errorf("node %s does not have a pending %s withdrawal address", m.NodeAddress.Hex(), setting)
+	}
+	if opts.From != pending {
+		return fmt.Errorf("only the pending %s withdrawal address %s can confirm it", setting, pending.Hex())
+	}
+	return nil
+}
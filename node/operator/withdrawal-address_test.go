@@ -0,0 +1,170 @@
+package operator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	withdrawalTestNode       = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	withdrawalTestPrimary    = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	withdrawalTestPending    = common.HexToAddress("0x3333333333333333333333333333333333333333")
+	withdrawalTestRpl        = common.HexToAddress("0x4444444444444444444444444444444444444444")
+	withdrawalTestRplPending = common.HexToAddress("0x5555555555555555555555555555555555555555")
+)
+
+func TestWithdrawalAddressManagerGetPermissions(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(m *WithdrawalAddressManager)
+		expected []WithdrawalAddressPermission
+	}{
+		{
+			name:   "before Houston",
+			modify: func(m *WithdrawalAddressManager) { m.HoustonRules = false },
+			expected: []WithdrawalAddressPermission{
+				{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Set, Address: withdrawalTestPrimary},
+			},
+		},
+		{
+			name: "before Houston with a pending address",
+			modify: func(m *WithdrawalAddressManager) {
+				m.HoustonRules = false
+				m.PendingWithdrawalAddress = withdrawalTestPending
+			},
+			expected: []WithdrawalAddressPermission{
+				{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Set, Address: withdrawalTestPrimary},
+				{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Confirm, Address: withdrawalTestPending},
+			},
+		},
+		{
+			name: "RPL address not set",
+			expected: []WithdrawalAddressPermission{
+				{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Set, Address: withdrawalTestPrimary},
+				{Setting: WithdrawalAddressSetting_Rpl, Action: WithdrawalAddressAction_Set, Address: withdrawalTestPrimary},
+			},
+		},
+		{
+			name: "RPL address set with pending changes",
+			modify: func(m *WithdrawalAddressManager) {
+				m.RplWithdrawalAddressIsSet = true
+				m.RplWithdrawalAddress = withdrawalTestRpl
+				m.PendingWithdrawalAddress = withdrawalTestPending
+				m.PendingRplWithdrawalAddress = withdrawalTestRplPending
+			},
+			expected: []WithdrawalAddressPermission{
+				{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Set, Address: withdrawalTestPrimary},
+				{Setting: WithdrawalAddressSetting_Primary, Action: WithdrawalAddressAction_Confirm, Address: withdrawalTestPending},
+				{Setting: WithdrawalAddressSetting_Rpl, Action: WithdrawalAddressAction_Set, Address: withdrawalTestRpl},
+				{Setting: WithdrawalAddressSetting_Rpl, Action: WithdrawalAddressAction_Confirm, Address: withdrawalTestRplPending},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := &WithdrawalAddressManager{
+				NodeAddress:       withdrawalTestNode,
+				HoustonRules:      true,
+				WithdrawalAddress: withdrawalTestPrimary,
+			}
+			if test.modify != nil {
+				test.modify(manager)
+			}
+			if permissions := manager.GetPermissions(); !reflect.DeepEqual(permissions, test.expected) {
+				t.Errorf("expected permissions %+v but got %+v", test.expected, permissions)
+			}
+		})
+	}
+}
+
+func TestWithdrawalAddressManagerCheckSet(t *testing.T) {
+	newAddress := common.HexToAddress("0x6666666666666666666666666666666666666666")
+
+	tests := []struct {
+		name          string
+		houston       bool
+		rplSet        bool
+		setting       WithdrawalAddressSetting
+		address       common.Address
+		from          common.Address
+		expectedError string
+	}{
+		{name: "primary", setting: WithdrawalAddressSetting_Primary, address: newAddress, from: withdrawalTestPrimary},
+		{name: "primary from the node", setting: WithdrawalAddressSetting_Primary, address: newAddress, from: withdrawalTestNode, expectedError: "only " + withdrawalTestPrimary.Hex() + " can set the primary withdrawal address"},
+		{name: "primary to zero", setting: WithdrawalAddressSetting_Primary, from: withdrawalTestPrimary, expectedError: "zero address"},
+		{name: "RPL before Houston", setting: WithdrawalAddressSetting_Rpl, address: newAddress, from: withdrawalTestPrimary, expectedError: "not supported before Houston"},
+		{name: "RPL not set", houston: true, setting: WithdrawalAddressSetting_Rpl, address: newAddress, from: withdrawalTestPrimary},
+		{name: "RPL set by the primary address", houston: true, rplSet: true, setting: WithdrawalAddressSetting_Rpl, address: newAddress, from: withdrawalTestPrimary, expectedError: "only " + withdrawalTestRpl.Hex() + " can set the rpl withdrawal address"},
+		{name: "RPL set by the RPL address", houston: true, rplSet: true, setting: WithdrawalAddressSetting_Rpl, address: newAddress, from: withdrawalTestRpl},
+		{name: "primary while the RPL address is set", houston: true, rplSet: true, setting: WithdrawalAddressSetting_Primary, address: newAddress, from: withdrawalTestRpl, expectedError: "only " + withdrawalTestPrimary.Hex()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := &WithdrawalAddressManager{
+				NodeAddress:       withdrawalTestNode,
+				HoustonRules:      test.houston,
+				WithdrawalAddress: withdrawalTestPrimary,
+			}
+			if test.rplSet {
+				manager.RplWithdrawalAddressIsSet = true
+				manager.RplWithdrawalAddress = withdrawalTestRpl
+			}
+			err := manager.checkSet(test.setting, test.address, &bind.TransactOpts{From: test.from})
+			checkWithdrawalAddressError(t, test.expectedError, err)
+		})
+	}
+}
+
+func TestWithdrawalAddressManagerCheckConfirm(t *testing.T) {
+	tests := []struct {
+		name          string
+		houston       bool
+		pending       bool
+		setting       WithdrawalAddressSetting
+		from          common.Address
+		expectedError string
+	}{
+		{name: "primary", pending: true, setting: WithdrawalAddressSetting_Primary, from: withdrawalTestPending},
+		{name: "primary from the current address", pending: true, setting: WithdrawalAddressSetting_Primary, from: withdrawalTestPrimary, expectedError: "only the pending primary withdrawal address " + withdrawalTestPending.Hex()},
+		{name: "primary without a pending address", setting: WithdrawalAddressSetting_Primary, from: withdrawalTestPending, expectedError: "does not have a pending primary withdrawal address"},
+		{name: "RPL before Houston", pending: true, setting: WithdrawalAddressSetting_Rpl, from: withdrawalTestRplPending, expectedError: "not supported before Houston"},
+		{name: "RPL", houston: true, pending: true, setting: WithdrawalAddressSetting_Rpl, from: withdrawalTestRplPending},
+		{name: "RPL from the primary pending address", houston: true, pending: true, setting: WithdrawalAddressSetting_Rpl, from: withdrawalTestPending, expectedError: "only the pending rpl withdrawal address " + withdrawalTestRplPending.Hex()},
+		{name: "RPL without a pending address", houston: true, setting: WithdrawalAddressSetting_Rpl, from: withdrawalTestRplPending, expectedError: "does not have a pending rpl withdrawal address"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manager := &WithdrawalAddressManager{
+				NodeAddress:       withdrawalTestNode,
+				HoustonRules:      test.houston,
+				WithdrawalAddress: withdrawalTestPrimary,
+			}
+			if test.pending {
+				manager.PendingWithdrawalAddress = withdrawalTestPending
+				manager.PendingRplWithdrawalAddress = withdrawalTestRplPending
+			}
+			err := manager.checkConfirm(test.setting, &bind.TransactOpts{From: test.from})
+			checkWithdrawalAddressError(t, test.expectedError, err)
+		})
+	}
+}
+
+func checkWithdrawalAddressError(t *testing.T, expected string, err error) {
+	t.Helper()
+	if expected == "" {
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected an error containing %q but got %v", expected, err)
+	}
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // Fall back to an embedded IANA database on systems that don't have one
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	rpstrings "github.com/rocket-pool/rocketpool-go/utils/strings"
+	"golang.org/x/sync/errgroup"
+)
+
+// Check that a timezone location is a location in the IANA database, e.g. "Europe/Berlin"
+func ValidateTimezoneLocation(timezoneLocation string) error {
+	// LoadLocation treats these as special names rather than database entries
+	if timezoneLocation == "" || timezoneLocation == "Local" {
+		return fmt.Errorf("'%s' is not a timezone location", timezoneLocation)
+	}
+	// Some system databases have copies of every location under these, but they aren't location names
+	if strings.HasPrefix(timezoneLocation, "posix/") || strings.HasPrefix(timezoneLocation, "right/") {
+		return fmt.Errorf("'%s' is not a timezone location", timezoneLocation)
+	}
+	if timezoneLocation != rpstrings.Sanitize(timezoneLocation) {
+		return fmt.Errorf("timezone location contains invalid characters")
+	}
+	_, err := time.LoadLocation(timezoneLocation)
+	return err
+}
+
+// Get the number of nodes in each timezone, loading the counts in pages of nodes
+func GetAllNodeCountPerTimezone(rp *rocketpool.RocketPool, opts *bind.CallOpts) ([]TimezoneCount, error) {
+	// Get the number of nodes
+	nodeCount, err := GetNodeCount(rp, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	iterations := uint64(math.Ceil(float64(nodeCount) / float64(TimezoneCountBatchSize)))
+	iterationCounts := make([][]TimezoneCount, iterations)
+
+	// Load the counts
+	var wg errgroup.Group
+	wg.SetLimit(timezoneCountThreadLimit)
+	for i := uint64(0); i < iterations; i++ {
+		i := i
+		offset := i * TimezoneCountBatchSize
+		limit := TimezoneCountBatchSize
+		if nodeCount-offset < TimezoneCountBatchSize {
+			limit = nodeCount - offset
+		}
+		wg.Go(func() error {
+			counts, err := GetNodeCountPerTimezone(rp, big.NewInt(0).SetUint64(offset), big.NewInt(0).SetUint64(limit), opts)
+			if err != nil {
+				return fmt.Errorf("error getting timezone counts for batch starting at %d: %w", offset, err)
+			}
+			iterationCounts[i] = counts
+			return nil
+		})
+	}
+	if err := wg.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Merge the pages
+	totals := map[string]*big.Int{}
+	for _, counts := range iterationCounts {
+		for _, count := range counts {
+			total, exists := totals[count.Timezone]
+			if !exists {
+				total = big.NewInt(0)
+				totals[count.Timezone] = total
+			}
+			total.Add(total, count.Count)
+		}
+	}
+	timezoneCounts := make([]TimezoneCount, 0, len(totals))
+	for timezone, count := range totals {
+		timezoneCounts = append(timezoneCounts, TimezoneCount{
+			Timezone: timezone,
+			Count:    count,
+		})
+	}
+	sort.Slice(timezoneCounts, func(i, j int) bool {
+		return timezoneCounts[i].Timezone < timezoneCounts[j].Timezone
+	})
+	return timezoneCounts, nil
+}
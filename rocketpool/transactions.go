@@ -0,0 +1,32 @@
+package rocketpool
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Send a batch of transactions in order, calling send with the index of each one.
+// Each transaction gets its own copy of opts with the original gas limit, because Transact fills in the limit.
+// If opts has a nonce, it's incremented for each transaction. On error, the hashes of the transactions already sent are returned.
+func SendTransactions(count int, opts *bind.TransactOpts, send func(i int, opts *bind.TransactOpts) (common.Hash, error)) ([]common.Hash, error) {
+	txOpts := *opts
+	if opts.Nonce != nil {
+		txOpts.Nonce = big.NewInt(0).Set(opts.Nonce)
+	}
+
+	hashes := []common.Hash{}
+	for i := 0; i < count; i++ {
+		txOpts.GasLimit = opts.GasLimit
+		hash, err := send(i, &txOpts)
+		if err != nil {
+			return hashes, err
+		}
+		hashes = append(hashes, hash)
+		if txOpts.Nonce != nil {
+			txOpts.Nonce.Add(txOpts.Nonce, big.NewInt(1))
+		}
+	}
+	return hashes, nil
+}
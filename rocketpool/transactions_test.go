@@ -0,0 +1,98 @@
+package rocketpool
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestSendTransactions(t *testing.T) {
+	sendErr := errors.New("send failed")
+
+	tests := []struct {
+		name           string
+		count          int
+		nonce          *big.Int
+		gasLimit       uint64
+		failAt         int // -1 to never fail
+		expectedNonces []int64
+		expectedHashes int
+	}{
+		{name: "no transactions", count: 0, failAt: -1},
+		{name: "no nonce", count: 3, failAt: -1, expectedNonces: []int64{-1, -1, -1}, expectedHashes: 3},
+		{name: "nonce", count: 3, nonce: big.NewInt(5), failAt: -1, expectedNonces: []int64{5, 6, 7}, expectedHashes: 3},
+		{name: "gas limit", count: 3, nonce: big.NewInt(5), gasLimit: 100000, failAt: -1, expectedNonces: []int64{5, 6, 7}, expectedHashes: 3},
+		{name: "first fails", count: 3, nonce: big.NewInt(5), failAt: 0, expectedNonces: []int64{5}, expectedHashes: 0},
+		{name: "last fails", count: 3, nonce: big.NewInt(5), failAt: 2, expectedNonces: []int64{5, 6, 7}, expectedHashes: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := &bind.TransactOpts{
+				Nonce:    test.nonce,
+				GasLimit: test.gasLimit,
+			}
+			var originalNonce *big.Int
+			if test.nonce != nil {
+				originalNonce = big.NewInt(0).Set(test.nonce)
+			}
+
+			nonces := []int64{}
+			hashes, err := SendTransactions(test.count, opts, func(i int, txOpts *bind.TransactOpts) (common.Hash, error) {
+				if txOpts == opts {
+					t.Errorf("transaction %d got the caller's opts", i)
+				}
+				if txOpts.GasLimit != test.gasLimit {
+					t.Errorf("transaction %d got a gas limit of %d instead of %d", i, txOpts.GasLimit, test.gasLimit)
+				}
+				if txOpts.Nonce == nil {
+					nonces = append(nonces, -1)
+				} else {
+					nonces = append(nonces, txOpts.Nonce.Int64())
+				}
+
+				// Transact fills in the gas limit, which must not carry over to the next transaction
+				txOpts.GasLimit = 21000 + uint64(i)
+				if i == test.failAt {
+					return common.Hash{}, sendErr
+				}
+				return common.BigToHash(big.NewInt(int64(i + 1))), nil
+			})
+
+			if test.failAt >= 0 {
+				if !errors.Is(err, sendErr) {
+					t.Errorf("expected the send error but got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if len(hashes) != test.expectedHashes {
+				t.Fatalf("expected %d hashes but got %d", test.expectedHashes, len(hashes))
+			}
+			for i, hash := range hashes {
+				if expected := common.BigToHash(big.NewInt(int64(i + 1))); hash != expected {
+					t.Errorf("expected hash %d to be %s but got %s", i, expected.Hex(), hash.Hex())
+				}
+			}
+			if len(nonces) != len(test.expectedNonces) {
+				t.Fatalf("expected %d transactions but got %d", len(test.expectedNonces), len(nonces))
+			}
+			for i, nonce := range nonces {
+				if nonce != test.expectedNonces[i] {
+					t.Errorf("expected transaction %d to have nonce %d but got %d", i, test.expectedNonces[i], nonce)
+				}
+			}
+
+			// The caller's opts are left alone
+			if opts.GasLimit != test.gasLimit {
+				t.Errorf("the caller's gas limit changed to %d", opts.GasLimit)
+			}
+			if (opts.Nonce == nil) != (originalNonce == nil) || (opts.Nonce != nil && opts.Nonce.Cmp(originalNonce) != 0) {
+				t.Errorf("the caller's nonce changed from %v to %v", originalNonce, opts.Nonce)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -15,3 +16,39 @@ func GetNodeSalt(nodeAddress common.Address, salt *big.Int) common.Hash {
 	saltHash := crypto.Keccak256Hash(nodeAddress.Bytes(), saltBytes[:])
 	return saltHash
 }
+
+// Make sure a salt fits in the uint256 it is on-chain
+func checkSalt(salt *big.Int) error {
+	if salt.Sign() < 0 {
+		return fmt.Errorf("salt %s is negative", salt.String())
+	}
+	if salt.BitLen() > 256 {
+		return fmt.Errorf("salt %s is larger than 256 bits", salt.String())
+	}
+	return nil
+}
+
+// The EIP-1167 minimal proxy bytecode the minipool factory deploys, split around the implementation address
+var (
+	minimalProxyPrefix = common.FromHex("0x3d602d80600a3d3981f3363d3d373d3d3d363d73")
+	minimalProxySuffix = common.FromHex("0x5af43d82803e903d91602b57fd5bf3")
+)
+
+// Get the init code hash of a minimal proxy (clone) of the given contract; minipools are clones of rocketMinipoolBase
+func GetMinimalProxyInitCodeHash(implementationAddress common.Address) common.Hash {
+	return crypto.Keccak256Hash(minimalProxyPrefix, implementationAddress.Bytes(), minimalProxySuffix)
+}
+
+// Get the address a contract deployed with CREATE2 will have
+func GetCreate2Address(deployerAddress common.Address, salt common.Hash, initCodeHash common.Hash) common.Address {
+	return crypto.CreateAddress2(deployerAddress, salt, initCodeHash.Bytes())
+}
+
+// Get the address of a minipool offline; this matches the factory's getExpectedAddress
+func GetExpectedMinipoolAddress(factoryAddress common.Address, initCodeHash common.Hash, nodeAddress common.Address, salt *big.Int) (common.Address, error) {
+	if err := checkSalt(salt); err != nil {
+		return common.Address{}, err
+	}
+	nodeSalt := GetNodeSalt(nodeAddress, salt)
+	return GetCreate2Address(factoryAddress, nodeSalt, initCodeHash), nil
+}
@@ -0,0 +1,176 @@
+package utils
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The examples from EIP-1014
+func TestGetCreate2Address(t *testing.T) {
+	tests := []struct {
+		deployer string
+		salt     string
+		initCode string
+		expected string
+	}{
+		{"0x0000000000000000000000000000000000000000", "0x00", "0x00", "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x00", "0x00", "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3"},
+		{"0xdeadbeef00000000000000000000000000000000", "0x000000000000000000000000feed000000000000000000000000000000000000", "0x00", "0xD04116cDd17beBE565EB2422F2497E06cC1C9833"},
+		{"0x0000000000000000000000000000000000000000", "0x00", "0xdeadbeef", "0x70f2b2914A2a4b783FaEFb75f459A580616Fcb5e"},
+		{"0x00000000000000000000000000000000deadbeef", "0xcafebabe", "0xdeadbeef", "0x60f3f640a8508fC6a86d45DF051962668E1e8AC7"},
+		{"0x00000000000000000000000000000000deadbeef", "0xcafebabe", "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef", "0x1d8bfDC5D46DC4f61D6b6115972536eBE6A8854C"},
+		{"0x0000000000000000000000000000000000000000", "0x00", "0x", "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0"},
+	}
+	for _, test := range tests {
+		address := GetCreate2Address(common.HexToAddress(test.deployer), common.HexToHash(test.salt), crypto.Keccak256Hash(common.FromHex(test.initCode)))
+		if address != common.HexToAddress(test.expected) {
+			t.Errorf("expected %s for deployer %s, salt %s and init code %s, got %s", test.expected, test.deployer, test.salt, test.initCode, address.Hex())
+		}
+	}
+}
+
+func TestGetExpectedMinipoolAddress(t *testing.T) {
+	factoryAddress := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	implementationAddress := common.HexToAddress("0x6666666666666666666666666666666666666666")
+	nodeAddress := common.HexToAddress("0x7777777777777777777777777777777777777777")
+
+	// The full EIP-1167 creation code for a clone of the implementation
+	initCodeHash := crypto.Keccak256Hash(common.FromHex("0x3d602d80600a3d3981f3363d3d373d3d3d363d73" + "6666666666666666666666666666666666666666" + "5af43d82803e903d91602b57fd5bf3"))
+	if hash := GetMinimalProxyInitCodeHash(implementationAddress); hash != initCodeHash {
+		t.Fatalf("expected init code hash %s, got %s", initCodeHash.Hex(), hash.Hex())
+	}
+
+	maxSalt := big.NewInt(0).Sub(big.NewInt(0).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	tests := []struct {
+		name string
+		salt *big.Int
+		// The node address and the salt as a uint256, packed
+		packed string
+		err    string
+	}{
+		{
+			name:   "zero",
+			salt:   big.NewInt(0),
+			packed: "0x7777777777777777777777777777777777777777" + "0000000000000000000000000000000000000000000000000000000000000000",
+		},
+		{
+			name:   "small",
+			salt:   big.NewInt(0x1234),
+			packed: "0x7777777777777777777777777777777777777777" + "0000000000000000000000000000000000000000000000000000000000001234",
+		},
+		{
+			name:   "largest uint256",
+			salt:   maxSalt,
+			packed: "0x7777777777777777777777777777777777777777" + "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		},
+		{
+			name: "negative",
+			salt: big.NewInt(-1),
+			err:  "negative",
+		},
+		{
+			name: "wider than uint256",
+			salt: big.NewInt(0).Add(maxSalt, big.NewInt(1)),
+			err:  "larger than 256 bits",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			address, err := GetExpectedMinipoolAddress(factoryAddress, initCodeHash, nodeAddress, test.salt)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing '%s', got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			nodeSalt := crypto.Keccak256Hash(common.FromHex(test.packed))
+			expected := crypto.CreateAddress2(factoryAddress, nodeSalt, initCodeHash.Bytes())
+			if address != expected {
+				t.Errorf("expected %s, got %s", expected.Hex(), address.Hex())
+			}
+		})
+	}
+}
+
+func TestFindVanitySalt(t *testing.T) {
+	search := VanitySaltSearch{
+		FactoryAddress: common.HexToAddress("0x5555555555555555555555555555555555555555"),
+		InitCodeHash:   GetMinimalProxyInitCodeHash(common.HexToAddress("0x6666666666666666666666666666666666666666")),
+		NodeAddress:    common.HexToAddress("0x7777777777777777777777777777777777777777"),
+		Threads:        2,
+	}
+	tests := []struct {
+		name   string
+		prefix string
+		suffix string
+		err    string
+	}{
+		{name: "prefix", prefix: "0x0"},
+		{name: "suffix with 0x", suffix: "0xA"},
+		{name: "prefix and suffix", prefix: "f", suffix: "f"},
+		{name: "nothing to search for", err: "a prefix or suffix is required"},
+		{name: "not hex", prefix: "0xg", err: "not a hex string"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			search := search
+			search.Prefix = test.prefix
+			search.Suffix = test.suffix
+			result, err := FindVanitySalt(context.Background(), search, nil)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error containing '%s', got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			address, err := GetExpectedMinipoolAddress(search.FactoryAddress, search.InitCodeHash, search.NodeAddress, result.Salt)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if address != result.Address {
+				t.Errorf("salt %s gives %s, not %s", result.Salt, address.Hex(), result.Address.Hex())
+			}
+			addressHex := strings.ToLower(result.Address.Hex())
+			if !strings.HasPrefix(addressHex, "0x"+strings.ToLower(strings.TrimPrefix(test.prefix, "0x"))) ||
+				!strings.HasSuffix(addressHex, strings.ToLower(strings.TrimPrefix(test.suffix, "0x"))) {
+				t.Errorf("address %s doesn't match prefix '%s' and suffix '%s'", result.Address.Hex(), test.prefix, test.suffix)
+			}
+		})
+	}
+}
+
+func TestFindVanitySaltProgressStops(t *testing.T) {
+	search := VanitySaltSearch{
+		FactoryAddress:   common.HexToAddress("0x5555555555555555555555555555555555555555"),
+		InitCodeHash:     GetMinimalProxyInitCodeHash(common.HexToAddress("0x6666666666666666666666666666666666666666")),
+		NodeAddress:      common.HexToAddress("0x7777777777777777777777777777777777777777"),
+		Prefix:           "000",
+		Threads:          2,
+		ProgressInterval: time.Microsecond,
+	}
+	var returned int32
+	_, err := FindVanitySalt(context.Background(), search, func(checked uint64) {
+		if atomic.LoadInt32(&returned) == 1 {
+			t.Errorf("progress was reported after the search returned")
+		}
+	})
+	atomic.StoreInt32(&returned, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	time.Sleep(10 * time.Millisecond)
+}
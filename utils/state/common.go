@@ -3,6 +3,8 @@ package state
 import (
 	"math/big"
 	"time"
+
+	"github.com/hashicorp/go-version"
 )
 
 const (
@@ -11,6 +13,7 @@ const (
 
 // Global constants
 var zero = big.NewInt(0)
+var houstonVersion, _ = version.NewSemver("1.3.0")
 
 // Converts a time on the chain (as Unix time in seconds) to a time.Time struct
 func convertToTime(value *big.Int) time.Time {
@@ -26,10 +26,12 @@ type NetworkContracts struct {
 	// Redstone
 	RocketDAONodeTrusted                 *rocketpool.Contract
 	RocketDAONodeTrustedSettingsMinipool *rocketpool.Contract
+	RocketDAOProtocolSettingsDeposit     *rocketpool.Contract
 	RocketDAOProtocolSettingsMinipool    *rocketpool.Contract
 	RocketDAOProtocolSettingsNetwork     *rocketpool.Contract
 	RocketDAOProtocolSettingsNode        *rocketpool.Contract
 	RocketDepositPool                    *rocketpool.Contract
+	RocketMerkleDistributorMainnet       *rocketpool.Contract
 	RocketMinipoolManager                *rocketpool.Contract
 	RocketMinipoolQueue                  *rocketpool.Contract
 	RocketNetworkBalances                *rocketpool.Contract
@@ -52,6 +54,7 @@ type NetworkContracts struct {
 	// Houston
 	RocketDAOProtocolProposal *rocketpool.Contract
 	RocketDAOProtocolVerifier *rocketpool.Contract
+	RocketNetworkVoting       *rocketpool.Contract
 }
 
 type contractArtifacts struct {
@@ -101,6 +104,9 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 		}, {
 			name:     "rocketDAONodeTrustedSettingsMinipool",
 			contract: &contracts.RocketDAONodeTrustedSettingsMinipool,
+		}, {
+			name:     "rocketDAOProtocolSettingsDeposit",
+			contract: &contracts.RocketDAOProtocolSettingsDeposit,
 		}, {
 			name:     "rocketDAOProtocolSettingsMinipool",
 			contract: &contracts.RocketDAOProtocolSettingsMinipool,
@@ -113,6 +119,9 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 		}, {
 			name:     "rocketDepositPool",
 			contract: &contracts.RocketDepositPool,
+		}, {
+			name:     "rocketMerkleDistributorMainnet",
+			contract: &contracts.RocketMerkleDistributorMainnet,
 		}, {
 			name:     "rocketMinipoolManager",
 			contract: &contracts.RocketMinipoolManager,
@@ -171,6 +180,9 @@ func NewNetworkContracts(rp *rocketpool.RocketPool, multicallerAddress common.Ad
 	}, contractArtifacts{
 		name:     "rocketDAOProtocolVerifier",
 		contract: &contracts.RocketDAOProtocolVerifier,
+	}, contractArtifacts{
+		name:     "rocketNetworkVoting",
+		contract: &contracts.RocketNetworkVoting,
 	})
 
 	// Add the address and ABI getters to multicall
@@ -222,6 +234,27 @@ func (c *NetworkContracts) getCurrentVersion(rp *rocketpool.RocketPool) error {
 		BlockNumber: c.ElBlockNumber,
 	}
 
+	// Check for v1.3
+	nodeMgrVersion, err := rocketpool.GetContractVersion(rp, *c.RocketNodeManager.Address, opts)
+	if err != nil {
+		return fmt.Errorf("error checking node manager version: %w", err)
+	}
+	if nodeMgrVersion > 3 {
+		// Check for v1.3.1, which upgraded network voting; the contract doesn't exist before Houston
+		if *c.RocketNetworkVoting.Address != (common.Address{}) {
+			networkVotingVersion, err := rocketpool.GetContractVersion(rp, *c.RocketNetworkVoting.Address, opts)
+			if err != nil {
+				return fmt.Errorf("error checking network voting version: %w", err)
+			}
+			if networkVotingVersion > 1 {
+				c.Version, err = version.NewSemver("1.3.1")
+				return err
+			}
+		}
+		c.Version, err = version.NewSemver("1.3.0")
+		return err
+	}
+
 	// Check for v1.2
 	nodeStakingVersion, err := rocketpool.GetContractVersion(rp, *c.RocketNodeStaking.Address, opts)
 	if err != nil {
@@ -233,10 +266,6 @@ func (c *NetworkContracts) getCurrentVersion(rp *rocketpool.RocketPool) error {
 	}
 
 	// Check for v1.1
-	nodeMgrVersion, err := rocketpool.GetContractVersion(rp, *c.RocketNodeManager.Address, opts)
-	if err != nil {
-		return fmt.Errorf("error checking node manager version: %w", err)
-	}
 	if nodeMgrVersion > 1 {
 		c.Version, err = version.NewSemver("1.1.0")
 		return err
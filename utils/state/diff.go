@@ -0,0 +1,259 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// A minipool that moved to a new status
+type MinipoolStatusTransition struct {
+	MinipoolAddress common.Address       `json:"minipool_address"`
+	NodeAddress     common.Address       `json:"node_address"`
+	PreviousStatus  types.MinipoolStatus `json:"previous_status"`
+	NewStatus       types.MinipoolStatus `json:"new_status"`
+}
+
+// A minipool whose bond was reduced
+type MinipoolBondReduction struct {
+	MinipoolAddress common.Address `json:"minipool_address"`
+	NodeAddress     common.Address `json:"node_address"`
+	PreviousBond    *big.Int       `json:"previous_bond"`
+	NewBond         *big.Int       `json:"new_bond"`
+	PreviousFee     *big.Int       `json:"previous_fee"`
+	NewFee          *big.Int       `json:"new_fee"`
+}
+
+// A node whose RPL stake changed
+type NodeRplStakeChange struct {
+	NodeAddress   common.Address `json:"node_address"`
+	PreviousStake *big.Int       `json:"previous_stake"`
+	NewStake      *big.Int       `json:"new_stake"`
+	Delta         *big.Int       `json:"delta"`
+}
+
+// A node whose fee distributor balance changed
+type DistributorBalanceChange struct {
+	NodeAddress        common.Address `json:"node_address"`
+	DistributorAddress common.Address `json:"distributor_address"`
+	PreviousBalance    *big.Int       `json:"previous_balance"`
+	NewBalance         *big.Int       `json:"new_balance"`
+	Delta              *big.Int       `json:"delta"`
+}
+
+// A network setting that changed
+type NetworkSettingChange struct {
+	Setting       string `json:"setting"`
+	PreviousValue string `json:"previous_value"`
+	NewValue      string `json:"new_value"`
+}
+
+// The changes between two network states.
+// Each part of the diff is only populated if both states loaded the sections it depends on.
+type NetworkStateDiff struct {
+	PreviousBlock uint64 `json:"previous_block"`
+	NewBlock      uint64 `json:"new_block"`
+
+	// Minipools
+	NewMinipools              []common.Address           `json:"new_minipools"`
+	ExitedMinipools           []common.Address           `json:"exited_minipools"`  // Finalised between the two states
+	RemovedMinipools          []common.Address           `json:"removed_minipools"` // No longer registered (dissolved and closed)
+	MinipoolStatusTransitions []MinipoolStatusTransition `json:"minipool_status_transitions"`
+	BondReductions            []MinipoolBondReduction    `json:"bond_reductions"`
+
+	// Nodes
+	NewNodes                  []common.Address           `json:"new_nodes"`
+	RplStakeChanges           []NodeRplStakeChange       `json:"rpl_stake_changes"`
+	DistributorBalanceChanges []DistributorBalanceChange `json:"distributor_balance_changes"`
+
+	// Oracle DAO
+	OracleDaoMembersJoined []common.Address `json:"oracle_dao_members_joined"`
+	OracleDaoMembersLeft   []common.Address `json:"oracle_dao_members_left"`
+
+	// Network
+	NetworkSettingChanges []NetworkSettingChange `json:"network_setting_changes"`
+}
+
+// The network settings compared by Diff
+var diffedNetworkSettings = []struct {
+	name  string
+	value func(details *NetworkDetails) interface{}
+}{
+	{"MinCollateralFraction", func(d *NetworkDetails) interface{} { return d.MinCollateralFraction }},
+	{"MaxCollateralFraction", func(d *NetworkDetails) interface{} { return d.MaxCollateralFraction }},
+	{"IntervalDuration", func(d *NetworkDetails) interface{} { return d.IntervalDuration }},
+	{"NodeOperatorRewardsPercent", func(d *NetworkDetails) interface{} { return d.NodeOperatorRewardsPercent }},
+	{"TrustedNodeOperatorRewardsPercent", func(d *NetworkDetails) interface{} { return d.TrustedNodeOperatorRewardsPercent }},
+	{"ProtocolDaoRewardsPercent", func(d *NetworkDetails) interface{} { return d.ProtocolDaoRewardsPercent }},
+	{"RPLInflationIntervalRate", func(d *NetworkDetails) interface{} { return d.RPLInflationIntervalRate }},
+	{"ScrubPeriod", func(d *NetworkDetails) interface{} { return d.ScrubPeriod }},
+	{"SmoothingPoolAddress", func(d *NetworkDetails) interface{} { return d.SmoothingPoolAddress.Hex() }},
+	{"SubmitBalancesEnabled", func(d *NetworkDetails) interface{} { return d.SubmitBalancesEnabled }},
+	{"SubmitPricesEnabled", func(d *NetworkDetails) interface{} { return d.SubmitPricesEnabled }},
+	{"MinipoolLaunchTimeout", func(d *NetworkDetails) interface{} { return d.MinipoolLaunchTimeout }},
+	{"PenaltyThreshold", func(d *NetworkDetails) interface{} { return d.PenaltyThreshold }},
+	{"PenaltyPerRate", func(d *NetworkDetails) interface{} { return d.PenaltyPerRate }},
+	{"PenaltyMaxRate", func(d *NetworkDetails) interface{} { return d.PenaltyMaxRate }},
+	{"PromotionScrubPeriod", func(d *NetworkDetails) interface{} { return d.PromotionScrubPeriod }},
+	{"BondReductionWindowStart", func(d *NetworkDetails) interface{} { return d.BondReductionWindowStart }},
+	{"BondReductionWindowLength", func(d *NetworkDetails) interface{} { return d.BondReductionWindowLength }},
+	{"BondReductionEnabled", func(d *NetworkDetails) interface{} { return d.BondReductionEnabled }},
+	{"UserDistributeWindowStart", func(d *NetworkDetails) interface{} { return d.UserDistributeWindowStart }},
+	{"UserDistributeWindowLength", func(d *NetworkDetails) interface{} { return d.UserDistributeWindowLength }},
+	{"PricesSubmissionFrequency", func(d *NetworkDetails) interface{} { return d.PricesSubmissionFrequency }},
+	{"BalancesSubmissionFrequency", func(d *NetworkDetails) interface{} { return d.BalancesSubmissionFrequency }},
+}
+
+// Get the changes between two network states
+func Diff(previous *NetworkState, current *NetworkState) *NetworkStateDiff {
+	diff := &NetworkStateDiff{
+		PreviousBlock:             previous.ElBlockNumber,
+		NewBlock:                  current.ElBlockNumber,
+		NewMinipools:              []common.Address{},
+		ExitedMinipools:           []common.Address{},
+		RemovedMinipools:          []common.Address{},
+		MinipoolStatusTransitions: []MinipoolStatusTransition{},
+		BondReductions:            []MinipoolBondReduction{},
+		NewNodes:                  []common.Address{},
+		RplStakeChanges:           []NodeRplStakeChange{},
+		DistributorBalanceChanges: []DistributorBalanceChange{},
+		OracleDaoMembersJoined:    []common.Address{},
+		OracleDaoMembersLeft:      []common.Address{},
+		NetworkSettingChanges:     []NetworkSettingChange{},
+	}
+
+	bothHave := func(section NetworkStateSection) bool {
+		return previous.Sections.Has(section) && current.Sections.Has(section)
+	}
+
+	if bothHave(NetworkStateSection_Minipools) {
+		diffMinipools(previous, current, diff)
+	}
+	if bothHave(NetworkStateSection_Nodes) {
+		diffNodes(previous, current, diff)
+	}
+	if bothHave(NetworkStateSection_OracleDao) {
+		diffOracleDao(previous, current, diff)
+	}
+	if bothHave(NetworkStateSection_NetworkDetails) {
+		diffNetworkSettings(previous.NetworkDetails, current.NetworkDetails, diff)
+	}
+
+	return diff
+}
+
+// Check if the diff has no changes in it
+func (d *NetworkStateDiff) IsEmpty() bool {
+	return len(d.NewMinipools) == 0 &&
+		len(d.ExitedMinipools) == 0 &&
+		len(d.RemovedMinipools) == 0 &&
+		len(d.MinipoolStatusTransitions) == 0 &&
+		len(d.BondReductions) == 0 &&
+		len(d.NewNodes) == 0 &&
+		len(d.RplStakeChanges) == 0 &&
+		len(d.DistributorBalanceChanges) == 0 &&
+		len(d.OracleDaoMembersJoined) == 0 &&
+		len(d.OracleDaoMembersLeft) == 0 &&
+		len(d.NetworkSettingChanges) == 0
+}
+
+// Compare the minipools of two states
+func diffMinipools(previous *NetworkState, current *NetworkState, diff *NetworkStateDiff) {
+	for i := range current.MinipoolDetails {
+		mpd := &current.MinipoolDetails[i]
+		old, exists := previous.MinipoolDetailsByAddress[mpd.MinipoolAddress]
+		if !exists {
+			diff.NewMinipools = append(diff.NewMinipools, mpd.MinipoolAddress)
+			continue
+		}
+
+		if mpd.Finalised && !old.Finalised {
+			diff.ExitedMinipools = append(diff.ExitedMinipools, mpd.MinipoolAddress)
+		}
+		if mpd.Status != old.Status {
+			diff.MinipoolStatusTransitions = append(diff.MinipoolStatusTransitions, MinipoolStatusTransition{
+				MinipoolAddress: mpd.MinipoolAddress,
+				NodeAddress:     mpd.NodeAddress,
+				PreviousStatus:  old.Status,
+				NewStatus:       mpd.Status,
+			})
+		}
+		if mpd.NodeDepositBalance.Cmp(old.NodeDepositBalance) < 0 {
+			diff.BondReductions = append(diff.BondReductions, MinipoolBondReduction{
+				MinipoolAddress: mpd.MinipoolAddress,
+				NodeAddress:     mpd.NodeAddress,
+				PreviousBond:    old.NodeDepositBalance,
+				NewBond:         mpd.NodeDepositBalance,
+				PreviousFee:     old.NodeFee,
+				NewFee:          mpd.NodeFee,
+			})
+		}
+	}
+
+	for _, mpd := range previous.MinipoolDetails {
+		if _, exists := current.MinipoolDetailsByAddress[mpd.MinipoolAddress]; !exists {
+			diff.RemovedMinipools = append(diff.RemovedMinipools, mpd.MinipoolAddress)
+		}
+	}
+}
+
+// Compare the nodes of two states
+func diffNodes(previous *NetworkState, current *NetworkState, diff *NetworkStateDiff) {
+	for i := range current.NodeDetails {
+		node := &current.NodeDetails[i]
+		old, exists := previous.NodeDetailsByAddress[node.NodeAddress]
+		if !exists {
+			diff.NewNodes = append(diff.NewNodes, node.NodeAddress)
+			continue
+		}
+
+		if node.RplStake.Cmp(old.RplStake) != 0 {
+			diff.RplStakeChanges = append(diff.RplStakeChanges, NodeRplStakeChange{
+				NodeAddress:   node.NodeAddress,
+				PreviousStake: old.RplStake,
+				NewStake:      node.RplStake,
+				Delta:         big.NewInt(0).Sub(node.RplStake, old.RplStake),
+			})
+		}
+		if node.DistributorBalance.Cmp(old.DistributorBalance) != 0 {
+			diff.DistributorBalanceChanges = append(diff.DistributorBalanceChanges, DistributorBalanceChange{
+				NodeAddress:        node.NodeAddress,
+				DistributorAddress: node.FeeDistributorAddress,
+				PreviousBalance:    old.DistributorBalance,
+				NewBalance:         node.DistributorBalance,
+				Delta:              big.NewInt(0).Sub(node.DistributorBalance, old.DistributorBalance),
+			})
+		}
+	}
+}
+
+// Compare the Oracle DAO members of two states
+func diffOracleDao(previous *NetworkState, current *NetworkState, diff *NetworkStateDiff) {
+	for _, member := range current.OracleDaoMemberDetails {
+		if _, exists := previous.OracleDaoMemberDetailsByAddress[member.Address]; !exists {
+			diff.OracleDaoMembersJoined = append(diff.OracleDaoMembersJoined, member.Address)
+		}
+	}
+	for _, member := range previous.OracleDaoMemberDetails {
+		if _, exists := current.OracleDaoMemberDetailsByAddress[member.Address]; !exists {
+			diff.OracleDaoMembersLeft = append(diff.OracleDaoMembersLeft, member.Address)
+		}
+	}
+}
+
+// Compare the network settings of two states
+func diffNetworkSettings(previous *NetworkDetails, current *NetworkDetails, diff *NetworkStateDiff) {
+	for _, setting := range diffedNetworkSettings {
+		previousValue := fmt.Sprint(setting.value(previous))
+		newValue := fmt.Sprint(setting.value(current))
+		if previousValue != newValue {
+			diff.NetworkSettingChanges = append(diff.NetworkSettingChanges, NetworkSettingChange{
+				Setting:       setting.name,
+				PreviousValue: previousValue,
+				NewValue:      newValue,
+			})
+		}
+	}
+}
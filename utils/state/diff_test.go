@@ -0,0 +1,189 @@
+package state
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+func TestDiff(t *testing.T) {
+	nodeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	newNodeAddress := common.HexToAddress("0x1111111111111111111111111111111111112222")
+	minipoolAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	newMinipoolAddress := common.HexToAddress("0x2222222222222222222222222222222222223333")
+	memberAddress := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	newMemberAddress := common.HexToAddress("0x3333333333333333333333333333333333334444")
+	eth := func(amount int64) *big.Int {
+		return big.NewInt(0).Mul(big.NewInt(amount), big.NewInt(1e18))
+	}
+
+	// Both states start out the same; each case changes the current one
+	newState := func(block uint64) *NetworkState {
+		return &NetworkState{
+			ElBlockNumber: block,
+			Sections:      NetworkStateSection_All,
+			NetworkDetails: &NetworkDetails{
+				PenaltyThreshold: big.NewInt(510000000000000000),
+				ScrubPeriod:      12 * time.Hour,
+			},
+			NodeDetails: []NativeNodeDetails{{
+				NodeAddress:        nodeAddress,
+				RplStake:           eth(1000),
+				DistributorBalance: eth(1),
+			}},
+			MinipoolDetails: []NativeMinipoolDetails{{
+				MinipoolAddress:    minipoolAddress,
+				NodeAddress:        nodeAddress,
+				Status:             types.Staking,
+				NodeDepositBalance: eth(16),
+				NodeFee:            big.NewInt(150000000000000000),
+			}},
+			OracleDaoMemberDetails: []OracleDaoMemberDetails{{Address: memberAddress}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		change   func(current *NetworkState)
+		expected func(diff *NetworkStateDiff)
+	}{
+		{
+			name:     "no changes",
+			change:   func(current *NetworkState) {},
+			expected: func(diff *NetworkStateDiff) {},
+		},
+		{
+			name: "new minipool and node",
+			change: func(current *NetworkState) {
+				current.NodeDetails = append(current.NodeDetails, NativeNodeDetails{NodeAddress: newNodeAddress, RplStake: eth(0), DistributorBalance: eth(0)})
+				current.MinipoolDetails = append(current.MinipoolDetails, NativeMinipoolDetails{MinipoolAddress: newMinipoolAddress, NodeAddress: newNodeAddress})
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.NewNodes = []common.Address{newNodeAddress}
+				diff.NewMinipools = []common.Address{newMinipoolAddress}
+			},
+		},
+		{
+			name: "removed minipool",
+			change: func(current *NetworkState) {
+				current.MinipoolDetails = []NativeMinipoolDetails{}
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.RemovedMinipools = []common.Address{minipoolAddress}
+			},
+		},
+		{
+			name: "exited minipool",
+			change: func(current *NetworkState) {
+				current.MinipoolDetails[0].Finalised = true
+				current.MinipoolDetails[0].Status = types.Withdrawable
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.ExitedMinipools = []common.Address{minipoolAddress}
+				diff.MinipoolStatusTransitions = []MinipoolStatusTransition{{
+					MinipoolAddress: minipoolAddress,
+					NodeAddress:     nodeAddress,
+					PreviousStatus:  types.Staking,
+					NewStatus:       types.Withdrawable,
+				}}
+			},
+		},
+		{
+			name: "bond reduction",
+			change: func(current *NetworkState) {
+				current.MinipoolDetails[0].NodeDepositBalance = eth(8)
+				current.MinipoolDetails[0].NodeFee = big.NewInt(140000000000000000)
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.BondReductions = []MinipoolBondReduction{{
+					MinipoolAddress: minipoolAddress,
+					NodeAddress:     nodeAddress,
+					PreviousBond:    eth(16),
+					NewBond:         eth(8),
+					PreviousFee:     big.NewInt(150000000000000000),
+					NewFee:          big.NewInt(140000000000000000),
+				}}
+			},
+		},
+		{
+			name: "RPL stake and distributor balance",
+			change: func(current *NetworkState) {
+				current.NodeDetails[0].RplStake = eth(900)
+				current.NodeDetails[0].DistributorBalance = eth(3)
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.RplStakeChanges = []NodeRplStakeChange{{
+					NodeAddress:   nodeAddress,
+					PreviousStake: eth(1000),
+					NewStake:      eth(900),
+					Delta:         eth(-100),
+				}}
+				diff.DistributorBalanceChanges = []DistributorBalanceChange{{
+					NodeAddress:     nodeAddress,
+					PreviousBalance: eth(1),
+					NewBalance:      eth(3),
+					Delta:           eth(2),
+				}}
+			},
+		},
+		{
+			name: "Oracle DAO membership",
+			change: func(current *NetworkState) {
+				current.OracleDaoMemberDetails = []OracleDaoMemberDetails{{Address: newMemberAddress}}
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.OracleDaoMembersJoined = []common.Address{newMemberAddress}
+				diff.OracleDaoMembersLeft = []common.Address{memberAddress}
+			},
+		},
+		{
+			name: "network settings",
+			change: func(current *NetworkState) {
+				current.NetworkDetails.ScrubPeriod = 24 * time.Hour
+				current.NetworkDetails.PenaltyThreshold = big.NewInt(600000000000000000)
+			},
+			expected: func(diff *NetworkStateDiff) {
+				diff.NetworkSettingChanges = []NetworkSettingChange{
+					{Setting: "ScrubPeriod", PreviousValue: "12h0m0s", NewValue: "24h0m0s"},
+					{Setting: "PenaltyThreshold", PreviousValue: "510000000000000000", NewValue: "600000000000000000"},
+				}
+			},
+		},
+		{
+			name: "section missing from one state",
+			change: func(current *NetworkState) {
+				current.Sections = NetworkStateSection_NetworkDetails
+				current.NodeDetails = []NativeNodeDetails{}
+				current.MinipoolDetails = []NativeMinipoolDetails{}
+			},
+			expected: func(diff *NetworkStateDiff) {},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			previous := newState(100)
+			previous.updateIndices()
+			current := newState(200)
+			test.change(current)
+			current.updateIndices()
+
+			expected := Diff(&NetworkState{}, &NetworkState{})
+			expected.PreviousBlock = 100
+			expected.NewBlock = 200
+			test.expected(expected)
+
+			diff := Diff(previous, current)
+			if !reflect.DeepEqual(diff, expected) {
+				t.Errorf("unexpected diff:\nwant %+v\ngot  %+v", expected, diff)
+			}
+			if diff.IsEmpty() != expected.IsEmpty() {
+				t.Errorf("IsEmpty returned %t", diff.IsEmpty())
+			}
+		})
+	}
+}
@@ -63,16 +63,16 @@ type NativeMinipoolDetails struct {
 	UserShareOfBeaconBalance          *big.Int `json:"user_share_of_beacon_balance"`
 
 	// Atlas
-	UserDistributed              bool
-	Slashed                      bool
-	IsVacant                     bool
-	LastBondReductionTime        *big.Int
-	LastBondReductionPrevValue   *big.Int
-	LastBondReductionPrevNodeFee *big.Int
-	ReduceBondTime               *big.Int
-	ReduceBondCancelled          bool
-	ReduceBondValue              *big.Int
-	PreMigrationBalance          *big.Int
+	UserDistributed              bool     `json:"user_distributed"`
+	Slashed                      bool     `json:"slashed"`
+	IsVacant                     bool     `json:"is_vacant"`
+	LastBondReductionTime        *big.Int `json:"last_bond_reduction_time"`
+	LastBondReductionPrevValue   *big.Int `json:"last_bond_reduction_prev_value"`
+	LastBondReductionPrevNodeFee *big.Int `json:"last_bond_reduction_prev_node_fee"`
+	ReduceBondTime               *big.Int `json:"reduce_bond_time"`
+	ReduceBondCancelled          bool     `json:"reduce_bond_cancelled"`
+	ReduceBondValue              *big.Int `json:"reduce_bond_value"`
+	PreMigrationBalance          *big.Int `json:"pre_migration_balance"`
 }
 
 var sixteenEth = big.NewInt(0).Mul(big.NewInt(16), oneEth)
@@ -238,6 +238,55 @@ func CalculateCompleteMinipoolShares(rp *rocketpool.RocketPool, contracts *Netwo
 	return nil
 }
 
+// Calculate the same shares as CalculateCompleteMinipoolShares, but in Go instead of with calls to each minipool's delegate.
+// maxPenaltyRate is the network's maximum penalty rate (NetworkDetails.PenaltyMaxRate).
+func CalculateCompleteMinipoolSharesOffline(minipoolDetails []*NativeMinipoolDetails, beaconBalances []*big.Int, maxPenaltyRate *big.Int) {
+	for i, details := range minipoolDetails {
+		params := details.GetDistributionParameters(maxPenaltyRate)
+
+		// Calculate the Beacon shares
+		beaconBalance := beaconBalances[i]
+		if beaconBalance.Cmp(zero) > 0 {
+			details.NodeShareOfBeaconBalance = params.CalculateNodeShare(beaconBalance)
+			details.UserShareOfBeaconBalance = params.CalculateUserShare(beaconBalance)
+		} else {
+			details.NodeShareOfBeaconBalance = big.NewInt(0)
+			details.UserShareOfBeaconBalance = big.NewInt(0)
+		}
+
+		// Calculate the total balance
+		totalBalance := big.NewInt(0).Set(beaconBalance)          // Total balance = beacon balance
+		totalBalance.Add(totalBalance, details.Balance)           // Add contract balance
+		totalBalance.Sub(totalBalance, details.NodeRefundBalance) // Remove node refund
+
+		// Calculate the node and user shares
+		if totalBalance.Cmp(zero) > 0 {
+			details.NodeShareOfBalanceIncludingBeacon = params.CalculateNodeShare(totalBalance)
+			details.UserShareOfBalanceIncludingBeacon = params.CalculateUserShare(totalBalance)
+		} else {
+			details.NodeShareOfBalanceIncludingBeacon = big.NewInt(0)
+			details.UserShareOfBalanceIncludingBeacon = big.NewInt(0)
+		}
+	}
+}
+
+// Get the parameters needed to calculate the minipool's distribution offline.
+// The penalty rate is capped at maxPenaltyRate (NetworkDetails.PenaltyMaxRate), the same way rocketMinipoolPenalty caps it.
+func (details *NativeMinipoolDetails) GetDistributionParameters(maxPenaltyRate *big.Int) minipool.DistributionParameters {
+	penaltyRate := details.PenaltyRate
+	if penaltyRate != nil && maxPenaltyRate != nil && penaltyRate.Cmp(maxPenaltyRate) > 0 {
+		penaltyRate = maxPenaltyRate
+	}
+	return minipool.DistributionParameters{
+		Version:            details.Version,
+		NodeFee:            details.NodeFee,
+		NodeDepositBalance: details.NodeDepositBalance,
+		UserDepositBalance: details.UserDepositBalance,
+		NodeRefundBalance:  details.NodeRefundBalance,
+		PenaltyRate:        penaltyRate,
+	}
+}
+
 var oneEth = big.NewInt(1e18)
 
 // Get the bond and node fee of a minipool for the specified time
@@ -0,0 +1,270 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// A section of the network state that can be loaded
+type NetworkStateSection uint8
+
+const (
+	NetworkStateSection_NetworkDetails NetworkStateSection = 1 << iota
+	NetworkStateSection_Nodes
+	NetworkStateSection_Minipools
+	NetworkStateSection_OracleDao
+	NetworkStateSection_ProtocolDao
+	NetworkStateSection_VotingPower
+
+	NetworkStateSection_All = NetworkStateSection_NetworkDetails |
+		NetworkStateSection_Nodes |
+		NetworkStateSection_Minipools |
+		NetworkStateSection_OracleDao |
+		NetworkStateSection_ProtocolDao |
+		NetworkStateSection_VotingPower
+)
+
+// Check if the set of sections includes the provided section
+func (s NetworkStateSection) Has(section NetworkStateSection) bool {
+	return s&section == section
+}
+
+// A snapshot of the Rocket Pool network, loaded at a single block
+type NetworkState struct {
+	// Block the state was loaded at
+	ElBlockNumber    uint64    `json:"el_block_number"`
+	ElBlockTimestamp time.Time `json:"el_block_timestamp"`
+
+	// Protocol version at the state's block
+	ProtocolVersion string `json:"protocol_version"`
+
+	// The sections that were loaded into this state
+	Sections NetworkStateSection `json:"sections"`
+
+	// Network details
+	NetworkDetails *NetworkDetails `json:"network_details"`
+
+	// Node details
+	NodeDetails []NativeNodeDetails `json:"node_details"`
+
+	// Minipool details
+	MinipoolDetails []NativeMinipoolDetails `json:"minipool_details"`
+
+	// Oracle DAO details
+	OracleDaoMemberDetails []OracleDaoMemberDetails `json:"oracle_dao_member_details"`
+
+	// Protocol DAO proposals
+	ProtocolDaoProposalDetails []protocol.ProtocolDaoProposalDetails `json:"protocol_dao_proposal_details"`
+
+	// Voting power of each node at the state's block
+	NodeVotingPower map[common.Address]*big.Int `json:"node_voting_power"`
+
+	// Sum of the effective RPL stake of every node; only set if the node section was loaded
+	TotalEffectiveRPLStake *big.Int `json:"total_effective_rpl_stake"`
+
+	// Indices, rebuilt whenever the details change
+	NodeDetailsByAddress            map[common.Address]*NativeNodeDetails            `json:"-"`
+	MinipoolDetailsByAddress        map[common.Address]*NativeMinipoolDetails        `json:"-"`
+	MinipoolDetailsByNode           map[common.Address][]*NativeMinipoolDetails      `json:"-"`
+	MinipoolDetailsByPubkey         map[types.ValidatorPubkey]*NativeMinipoolDetails `json:"-"`
+	OracleDaoMemberDetailsByAddress map[common.Address]*OracleDaoMemberDetails       `json:"-"`
+}
+
+// Create a snapshot of the network, loading the provided sections at the contracts' block.
+// Sections that don't exist at the contracts' version are left out of the state's Sections.
+func NewNetworkState(rp *rocketpool.RocketPool, contracts *NetworkContracts, sections NetworkStateSection) (*NetworkState, error) {
+	// Voting power was added in Houston, so it can't be loaded before then
+	if contracts.Version == nil || contracts.Version.LessThan(houstonVersion) {
+		sections &^= NetworkStateSection_VotingPower
+	}
+
+	state := &NetworkState{
+		ElBlockNumber:   contracts.ElBlockNumber.Uint64(),
+		ProtocolVersion: contracts.Version.String(),
+		Sections:        sections,
+	}
+
+	err := state.loadBlockTimestamp(rp, contracts)
+	if err != nil {
+		return nil, err
+	}
+
+	if sections.Has(NetworkStateSection_NetworkDetails) {
+		state.NetworkDetails, err = NewNetworkDetails(rp, contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting network details: %w", err)
+		}
+	}
+
+	if sections.Has(NetworkStateSection_Nodes) {
+		state.NodeDetails, err = GetAllNativeNodeDetails(rp, contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting all node details: %w", err)
+		}
+	}
+
+	if sections.Has(NetworkStateSection_Minipools) {
+		state.MinipoolDetails, err = GetAllNativeMinipoolDetails(rp, contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting all minipool details: %w", err)
+		}
+	}
+
+	if sections.Has(NetworkStateSection_OracleDao) {
+		state.OracleDaoMemberDetails, err = GetAllOracleDaoMemberDetails(rp, contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Oracle DAO details: %w", err)
+		}
+	}
+
+	if sections.Has(NetworkStateSection_ProtocolDao) {
+		state.ProtocolDaoProposalDetails, err = GetAllProtocolDaoProposalDetails(rp, contracts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting Protocol DAO proposal details: %w", err)
+		}
+	}
+
+	if sections.Has(NetworkStateSection_VotingPower) {
+		err = state.loadVotingPower(rp, contracts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	state.updateIndices()
+	err = state.calculateDerivedFields()
+	if err != nil {
+		return nil, fmt.Errorf("error calculating derived fields: %w", err)
+	}
+
+	return state, nil
+}
+
+// Get the details of a node, or nil if it isn't in the state
+func (s *NetworkState) GetNode(nodeAddress common.Address) *NativeNodeDetails {
+	return s.NodeDetailsByAddress[nodeAddress]
+}
+
+// Get the details of a minipool, or nil if it isn't in the state
+func (s *NetworkState) GetMinipool(minipoolAddress common.Address) *NativeMinipoolDetails {
+	return s.MinipoolDetailsByAddress[minipoolAddress]
+}
+
+// Get the details of the minipool with the provided validator pubkey, or nil if it isn't in the state
+func (s *NetworkState) GetMinipoolByPubkey(pubkey types.ValidatorPubkey) *NativeMinipoolDetails {
+	return s.MinipoolDetailsByPubkey[pubkey]
+}
+
+// Get the minipools belonging to a node
+func (s *NetworkState) GetNodeMinipools(nodeAddress common.Address) []*NativeMinipoolDetails {
+	return s.MinipoolDetailsByNode[nodeAddress]
+}
+
+// Get the voting power of a node, or nil if voting power wasn't loaded
+func (s *NetworkState) GetNodeVotingPower(nodeAddress common.Address) *big.Int {
+	return s.NodeVotingPower[nodeAddress]
+}
+
+// Load the timestamp of the state's block
+func (s *NetworkState) loadBlockTimestamp(rp *rocketpool.RocketPool, contracts *NetworkContracts) error {
+	header, err := rp.Client.HeaderByNumber(context.Background(), contracts.ElBlockNumber)
+	if err != nil {
+		return fmt.Errorf("error getting header for block %d: %w", contracts.ElBlockNumber.Uint64(), err)
+	}
+	s.ElBlockTimestamp = time.Unix(int64(header.Time), 0)
+	return nil
+}
+
+// Load the voting power for every node
+func (s *NetworkState) loadVotingPower(rp *rocketpool.RocketPool, contracts *NetworkContracts) error {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+
+	// Reuse the node list if it's already been loaded
+	var addresses []common.Address
+	if s.Sections.Has(NetworkStateSection_Nodes) {
+		addresses = make([]common.Address, len(s.NodeDetails))
+		for i, details := range s.NodeDetails {
+			addresses[i] = details.NodeAddress
+		}
+	} else {
+		var err error
+		addresses, err = getNodeAddressesFast(rp, contracts, opts)
+		if err != nil {
+			return fmt.Errorf("error getting node addresses: %w", err)
+		}
+	}
+
+	powers, err := getNodeVotingPowerFast(rp, contracts, addresses, opts)
+	if err != nil {
+		return err
+	}
+	s.NodeVotingPower = make(map[common.Address]*big.Int, len(addresses))
+	for i, address := range addresses {
+		s.NodeVotingPower[address] = powers[i]
+	}
+	return nil
+}
+
+// Rebuild the lookup maps from the node, minipool and Oracle DAO details
+func (s *NetworkState) updateIndices() {
+	s.NodeDetailsByAddress = make(map[common.Address]*NativeNodeDetails, len(s.NodeDetails))
+	for i := range s.NodeDetails {
+		details := &s.NodeDetails[i]
+		s.NodeDetailsByAddress[details.NodeAddress] = details
+	}
+
+	s.MinipoolDetailsByAddress = make(map[common.Address]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	s.MinipoolDetailsByNode = map[common.Address][]*NativeMinipoolDetails{}
+	s.MinipoolDetailsByPubkey = make(map[types.ValidatorPubkey]*NativeMinipoolDetails, len(s.MinipoolDetails))
+	for i := range s.MinipoolDetails {
+		details := &s.MinipoolDetails[i]
+		s.MinipoolDetailsByAddress[details.MinipoolAddress] = details
+		s.MinipoolDetailsByNode[details.NodeAddress] = append(s.MinipoolDetailsByNode[details.NodeAddress], details)
+		s.MinipoolDetailsByPubkey[details.Pubkey] = details
+	}
+
+	s.OracleDaoMemberDetailsByAddress = make(map[common.Address]*OracleDaoMemberDetails, len(s.OracleDaoMemberDetails))
+	for i := range s.OracleDaoMemberDetails {
+		details := &s.OracleDaoMemberDetails[i]
+		s.OracleDaoMemberDetailsByAddress[details.Address] = details
+	}
+}
+
+// Calculate the fields that are derived from other sections in a single pass over the nodes
+func (s *NetworkState) calculateDerivedFields() error {
+	if !s.Sections.Has(NetworkStateSection_Nodes) {
+		return nil
+	}
+
+	s.TotalEffectiveRPLStake = big.NewInt(0)
+	hasMinipools := s.Sections.Has(NetworkStateSection_Minipools)
+	for i := range s.NodeDetails {
+		details := &s.NodeDetails[i]
+
+		// Effective stake (already zeroed for nodes under the minimum when loaded)
+		s.TotalEffectiveRPLStake.Add(s.TotalEffectiveRPLStake, details.EffectiveRPLStake)
+
+		// The average fee and distributor shares need the node's minipools
+		if hasMinipools {
+			details.AverageNodeFee = big.NewInt(0)
+			details.DistributorBalanceNodeETH = big.NewInt(0)
+			details.DistributorBalanceUserETH = big.NewInt(0)
+			err := details.CalculateAverageFeeAndDistributorShares(s.MinipoolDetailsByNode[details.NodeAddress])
+			if err != nil {
+				return fmt.Errorf("error calculating average fee and distributor shares for node %s: %w", details.NodeAddress.Hex(), err)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,146 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestNetworkStateUpdateIndices(t *testing.T) {
+	node1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	node2 := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	minipool1 := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	minipool2 := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	minipool3 := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	member := common.HexToAddress("0x6666666666666666666666666666666666666666")
+	state := &NetworkState{
+		NodeDetails: []NativeNodeDetails{{NodeAddress: node1}, {NodeAddress: node2}},
+		MinipoolDetails: []NativeMinipoolDetails{
+			{MinipoolAddress: minipool1, NodeAddress: node1, Pubkey: types.ValidatorPubkey{0x01}},
+			{MinipoolAddress: minipool2, NodeAddress: node2, Pubkey: types.ValidatorPubkey{0x02}},
+			{MinipoolAddress: minipool3, NodeAddress: node1, Pubkey: types.ValidatorPubkey{0x03}},
+		},
+		OracleDaoMemberDetails: []OracleDaoMemberDetails{{Address: member}},
+	}
+	state.updateIndices()
+
+	// The indices point into the detail slices rather than at copies
+	if state.GetNode(node2) != &state.NodeDetails[1] {
+		t.Errorf("node %s was not indexed", node2.Hex())
+	}
+	if state.GetMinipool(minipool3) != &state.MinipoolDetails[2] {
+		t.Errorf("minipool %s was not indexed by address", minipool3.Hex())
+	}
+	if state.GetMinipoolByPubkey(types.ValidatorPubkey{0x02}) != &state.MinipoolDetails[1] {
+		t.Errorf("minipool %s was not indexed by pubkey", minipool2.Hex())
+	}
+	if state.OracleDaoMemberDetailsByAddress[member] != &state.OracleDaoMemberDetails[0] {
+		t.Errorf("Oracle DAO member %s was not indexed", member.Hex())
+	}
+	nodeMinipools := state.GetNodeMinipools(node1)
+	if len(nodeMinipools) != 2 || nodeMinipools[0] != &state.MinipoolDetails[0] || nodeMinipools[1] != &state.MinipoolDetails[2] {
+		t.Errorf("unexpected minipools for node %s: %v", node1.Hex(), nodeMinipools)
+	}
+	if state.GetNode(member) != nil || state.GetMinipool(node1) != nil {
+		t.Error("expected nil for addresses that aren't in the state")
+	}
+
+	// Rebuilding drops entries that were removed
+	state.MinipoolDetails = state.MinipoolDetails[:1]
+	state.updateIndices()
+	if state.GetMinipool(minipool2) != nil || len(state.GetNodeMinipools(node1)) != 1 {
+		t.Error("removed minipools are still indexed")
+	}
+}
+
+func TestNetworkStateCalculateDerivedFields(t *testing.T) {
+	node1 := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	node2 := common.HexToAddress("0x2222222222222222222222222222222222222222")
+
+	tests := []struct {
+		name                 string
+		sections             NetworkStateSection
+		expectedTotalStake   *big.Int
+		expectedAverageFee   float64
+		expectedNodeShare    float64
+		expectedUserShare    float64
+		expectSharesUnloaded bool
+	}{
+		{
+			name:                 "No nodes",
+			sections:             NetworkStateSection_Minipools,
+			expectSharesUnloaded: true,
+		},
+		{
+			name:                 "Nodes without minipools",
+			sections:             NetworkStateSection_Nodes,
+			expectedTotalStake:   eth.EthToWei(3000),
+			expectSharesUnloaded: true,
+		},
+		{
+			// Only the staking minipools that aren't finalised count towards the average fee
+			name:               "Nodes and minipools",
+			sections:           NetworkStateSection_Nodes | NetworkStateSection_Minipools,
+			expectedTotalStake: eth.EthToWei(3000),
+			expectedAverageFee: 0.15,
+			expectedNodeShare:  0.575,
+			expectedUserShare:  0.425,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			state := &NetworkState{
+				Sections: test.sections,
+				NodeDetails: []NativeNodeDetails{
+					{NodeAddress: node1, EffectiveRPLStake: eth.EthToWei(2000), DistributorBalance: eth.EthToWei(1), CollateralisationRatio: eth.EthToWei(2)},
+					{NodeAddress: node2, EffectiveRPLStake: eth.EthToWei(1000), DistributorBalance: big.NewInt(0), CollateralisationRatio: big.NewInt(0)},
+				},
+				MinipoolDetails: []NativeMinipoolDetails{
+					{NodeAddress: node1, Status: types.Staking, NodeFee: eth.EthToWei(0.1)},
+					{NodeAddress: node1, Status: types.Staking, NodeFee: eth.EthToWei(0.2)},
+					{NodeAddress: node1, Status: types.Staking, Finalised: true, NodeFee: eth.EthToWei(0.05)},
+					{NodeAddress: node1, Status: types.Prelaunch, NodeFee: eth.EthToWei(0.05)},
+				},
+			}
+			state.updateIndices()
+			if err := state.calculateDerivedFields(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if test.expectedTotalStake == nil {
+				if state.TotalEffectiveRPLStake != nil {
+					t.Errorf("expected no total effective stake but got %s", state.TotalEffectiveRPLStake.String())
+				}
+			} else if state.TotalEffectiveRPLStake.Cmp(test.expectedTotalStake) != 0 {
+				t.Errorf("expected a total effective stake of %s but got %s", test.expectedTotalStake.String(), state.TotalEffectiveRPLStake.String())
+			}
+
+			details := state.GetNode(node1)
+			if test.expectSharesUnloaded {
+				if details.AverageNodeFee != nil || details.DistributorBalanceNodeETH != nil {
+					t.Errorf("expected the distributor shares to be left alone")
+				}
+				return
+			}
+			if fee := eth.WeiToEth(details.AverageNodeFee); fee != test.expectedAverageFee {
+				t.Errorf("expected an average fee of %f but got %f", test.expectedAverageFee, fee)
+			}
+			if share := eth.WeiToEth(details.DistributorBalanceNodeETH); share != test.expectedNodeShare {
+				t.Errorf("expected a node share of %f but got %f", test.expectedNodeShare, share)
+			}
+			if share := eth.WeiToEth(details.DistributorBalanceUserETH); share != test.expectedUserShare {
+				t.Errorf("expected a user share of %f but got %f", test.expectedUserShare, share)
+			}
+
+			// A node without minipools or a balance gets nothing
+			empty := state.GetNode(node2)
+			if empty.AverageNodeFee.Sign() != 0 || empty.DistributorBalanceNodeETH.Sign() != 0 || empty.DistributorBalanceUserETH.Sign() != 0 {
+				t.Errorf("expected zero fee and shares for node %s", node2.Hex())
+			}
+		})
+	}
+}
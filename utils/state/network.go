@@ -7,6 +7,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/rocket-pool/rocketpool-go/minipool"
 	"github.com/rocket-pool/rocketpool-go/rocketpool"
 	"github.com/rocket-pool/rocketpool-go/utils/eth"
@@ -54,12 +55,18 @@ type NetworkDetails struct {
 	SubmitBalancesEnabled             bool                   `json:"submit_balances_enabled"`
 	SubmitPricesEnabled               bool                   `json:"submit_prices_enabled"`
 	MinipoolLaunchTimeout             *big.Int               `json:"minipool_launch_timeout"`
+	PenaltyThreshold                  *big.Int               `json:"penalty_threshold"`
+	PenaltyPerRate                    *big.Int               `json:"penalty_per_rate"`
+	PenaltyMaxRate                    *big.Int               `json:"penalty_max_rate"`
 
 	// Atlas
-	PromotionScrubPeriod      time.Duration `json:"promotion_scrub_period"`
-	BondReductionWindowStart  time.Duration `json:"bond_reduction_window_start"`
-	BondReductionWindowLength time.Duration `json:"bond_reduction_window_length"`
-	DepositPoolUserBalance    *big.Int      `json:"deposit_pool_user_balance"`
+	PromotionScrubPeriod       time.Duration `json:"promotion_scrub_period"`
+	BondReductionWindowStart   time.Duration `json:"bond_reduction_window_start"`
+	BondReductionWindowLength  time.Duration `json:"bond_reduction_window_length"`
+	DepositPoolUserBalance     *big.Int      `json:"deposit_pool_user_balance"`
+	BondReductionEnabled       bool          `json:"bond_reduction_enabled"`
+	UserDistributeWindowStart  time.Duration `json:"user_distribute_window_start"`
+	UserDistributeWindowLength time.Duration `json:"user_distribute_window_length"`
 
 	// Houston
 	PricesSubmissionFrequency   uint64 `json:"prices_submission_frequency"`
@@ -93,6 +100,8 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) (
 	var promotionScrubPeriodSeconds *big.Int
 	var windowStartRaw *big.Int
 	var windowLengthRaw *big.Int
+	var userDistributeWindowStartRaw *big.Int
+	var userDistributeWindowLengthRaw *big.Int
 
 	// Multicall getters
 	contracts.Multicaller.AddCall(contracts.RocketNetworkPrices, &details.RplPrice, "getRPLPrice")
@@ -125,12 +134,18 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) (
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &details.SubmitBalancesEnabled, "getSubmitBalancesEnabled")
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &details.SubmitPricesEnabled, "getSubmitPricesEnabled")
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &minipoolLaunchTimeout, "getLaunchTimeout")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &details.PenaltyThreshold, "getNodePenaltyThreshold")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &details.PenaltyPerRate, "getPerPenaltyRate")
+	contracts.Multicaller.AddCall(contracts.RocketStorage, &details.PenaltyMaxRate, "getUint", crypto.Keccak256Hash([]byte("minipool.penalty.rate.max")))
 
 	// Atlas things
 	contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &promotionScrubPeriodSeconds, "getPromotionScrubPeriod")
 	contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &windowStartRaw, "getBondReductionWindowStart")
 	contracts.Multicaller.AddCall(contracts.RocketDAONodeTrustedSettingsMinipool, &windowLengthRaw, "getBondReductionWindowLength")
 	contracts.Multicaller.AddCall(contracts.RocketDepositPool, &details.DepositPoolUserBalance, "getUserBalance")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &details.BondReductionEnabled, "getBondReductionEnabled")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &userDistributeWindowStartRaw, "getUserDistributeWindowStart")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsMinipool, &userDistributeWindowLengthRaw, "getUserDistributeWindowLength")
 
 	// Houston
 	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNetwork, &pricesSubmissionFrequency, "getSubmitPricesFrequency")
@@ -164,6 +179,8 @@ func NewNetworkDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts) (
 	details.PromotionScrubPeriod = convertToDuration(promotionScrubPeriodSeconds)
 	details.BondReductionWindowStart = convertToDuration(windowStartRaw)
 	details.BondReductionWindowLength = convertToDuration(windowLengthRaw)
+	details.UserDistributeWindowStart = convertToDuration(userDistributeWindowStartRaw)
+	details.UserDistributeWindowLength = convertToDuration(userDistributeWindowLengthRaw)
 
 	// Get various balances
 	addresses := []common.Address{
@@ -0,0 +1,116 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+)
+
+// The claim status of a node for a rewards interval
+type NodeRewardsClaim struct {
+	Interval uint64 `json:"interval"`
+	Claimed  bool   `json:"claimed"`
+}
+
+// Everything about a single node, loaded without sweeping the rest of the network
+type NodeView struct {
+	ElBlockNumber uint64 `json:"el_block_number"`
+
+	// The node and its minipools; the node's average fee and distributor shares are already calculated
+	Node      NativeNodeDetails       `json:"node"`
+	Minipools []NativeMinipoolDetails `json:"minipools"`
+
+	// Voting, which is only loaded from Houston
+	VotingInitialized bool           `json:"voting_initialized"`
+	VotingPower       *big.Int       `json:"voting_power"`
+	VotingDelegate    common.Address `json:"voting_delegate"`
+
+	// Claim status for every completed rewards interval
+	RewardsClaims []NodeRewardsClaim `json:"rewards_claims"`
+
+	// Minipools that have started a bond reduction that hasn't been cancelled or completed yet
+	PendingBondReductions []*NativeMinipoolDetails `json:"pending_bond_reductions"`
+}
+
+// Load the view of a single node using a handful of multicalls
+func NewNodeView(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) (*NodeView, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	view := &NodeView{
+		ElBlockNumber: contracts.ElBlockNumber.Uint64(),
+		VotingPower:   big.NewInt(0),
+	}
+
+	// Node details
+	var err error
+	view.Node, err = GetNativeNodeDetails(rp, contracts, nodeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node details: %w", err)
+	}
+
+	// Voting, which only exists from Houston, and the current rewards interval
+	var rewardIndex *big.Int
+	if contracts.Version != nil && contracts.Version.GreaterThanOrEqual(houstonVersion) {
+		blockNumber := uint32(contracts.ElBlockNumber.Uint64())
+		contracts.Multicaller.AddCall(contracts.RocketNetworkVoting, &view.VotingInitialized, "getVotingInitialised", nodeAddress)
+		contracts.Multicaller.AddCall(contracts.RocketNetworkVoting, &view.VotingPower, "getVotingPower", nodeAddress, blockNumber)
+		contracts.Multicaller.AddCall(contracts.RocketNetworkVoting, &view.VotingDelegate, "getCurrentDelegate", nodeAddress)
+	}
+	contracts.Multicaller.AddCall(contracts.RocketRewardsPool, &rewardIndex, "getRewardIndex")
+	_, err = contracts.Multicaller.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+
+	// Rewards claims for every completed interval
+	intervalCount := rewardIndex.Uint64()
+	view.RewardsClaims = make([]NodeRewardsClaim, intervalCount)
+	for i := uint64(0); i < intervalCount; i++ {
+		view.RewardsClaims[i].Interval = i
+		contracts.Multicaller.AddCall(contracts.RocketMerkleDistributorMainnet, &view.RewardsClaims[i].Claimed, "isClaimed", big.NewInt(0).SetUint64(i), nodeAddress)
+	}
+	if intervalCount > 0 {
+		_, err = contracts.Multicaller.FlexibleCall(true, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error getting rewards claim status: %w", err)
+		}
+	}
+
+	// Minipools
+	view.Minipools, err = GetNodeNativeMinipoolDetails(rp, contracts, nodeAddress)
+	if err != nil {
+		return nil, fmt.Errorf("error getting minipool details: %w", err)
+	}
+	minipools := make([]*NativeMinipoolDetails, len(view.Minipools))
+	view.PendingBondReductions = []*NativeMinipoolDetails{}
+	for i := range view.Minipools {
+		mpd := &view.Minipools[i]
+		minipools[i] = mpd
+		if mpd.ReduceBondTime.Cmp(zero) > 0 && !mpd.ReduceBondCancelled {
+			view.PendingBondReductions = append(view.PendingBondReductions, mpd)
+		}
+	}
+
+	// Average fee and distributor shares
+	err = view.Node.CalculateAverageFeeAndDistributorShares(minipools)
+	if err != nil {
+		return nil, fmt.Errorf("error calculating average fee and distributor shares: %w", err)
+	}
+
+	return view, nil
+}
+
+// Get the rewards intervals the node hasn't claimed yet
+func (v *NodeView) GetUnclaimedIntervals() []uint64 {
+	intervals := []uint64{}
+	for _, claim := range v.RewardsClaims {
+		if !claim.Claimed {
+			intervals = append(intervals, claim.Interval)
+		}
+	}
+	return intervals
+}
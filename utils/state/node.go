@@ -137,6 +137,13 @@ func GetAllNativeNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContra
 	if err != nil {
 		return nil, fmt.Errorf("error getting node addresses: %w", err)
 	}
+
+	// Get the node details
+	return getBulkNodeDetails(rp, contracts, addresses, opts)
+}
+
+// Get multiple node details at once
+func getBulkNodeDetails(rp *rocketpool.RocketPool, contracts *NetworkContracts, addresses []common.Address, opts *bind.CallOpts) ([]NativeNodeDetails, error) {
 	count := len(addresses)
 	nodeDetails := make([]NativeNodeDetails, count)
 
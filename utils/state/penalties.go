@@ -0,0 +1,209 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// The penalty settings of the network
+type PenaltySettings struct {
+	Threshold *big.Int `json:"threshold"` // The fraction of the Oracle DAO that has to submit a penalty for it to apply
+	PerRate   *big.Int `json:"per_rate"`  // The penalty rate added to a minipool for each applied penalty
+	MaxRate   *big.Int `json:"max_rate"`  // The highest penalty rate that's applied, whatever a minipool's penalty count
+}
+
+// An RPL slashing of a node, from its RPLSlashed event
+type RplSlashing struct {
+	NodeAddress     common.Address `json:"node_address"`
+	MinipoolAddress common.Address `json:"minipool_address"` // Empty if the slashing couldn't be attributed to one of the node's minipools
+	Amount          *big.Int       `json:"amount"`
+	EthValue        *big.Int       `json:"eth_value"`
+	Time            time.Time      `json:"time"`
+	TxHash          common.Hash    `json:"tx_hash"`
+	BlockNumber     uint64         `json:"block_number"`
+}
+
+// The penalty and slashing exposure of a minipool
+type MinipoolPenaltyAccount struct {
+	MinipoolAddress common.Address `json:"minipool_address"`
+	PenaltyCount    uint64         `json:"penalty_count"`
+	PenaltyRate     *big.Int       `json:"penalty_rate"` // Capped at the maximum penalty rate
+
+	// The effect of the penalty rate on the node's share if the minipool exits with ExitBalance
+	ExitBalance             *big.Int `json:"exit_balance"`
+	NodeShareWithoutPenalty *big.Int `json:"node_share_without_penalty"`
+	NodeShareWithPenalty    *big.Int `json:"node_share_with_penalty"`
+	PenaltyAmount           *big.Int `json:"penalty_amount"`
+
+	RplSlashed   bool          `json:"rpl_slashed"`
+	RplSlashings []RplSlashing `json:"rpl_slashings"`
+}
+
+// The penalty and slashing exposure of a node across all of its minipools
+type NodePenaltyAccount struct {
+	NodeAddress common.Address           `json:"node_address"`
+	Settings    PenaltySettings          `json:"settings"`
+	Minipools   []MinipoolPenaltyAccount `json:"minipools"`
+
+	// Totals
+	PenaltyCount            uint64   `json:"penalty_count"`
+	PenalisedMinipoolCount  int      `json:"penalised_minipool_count"`
+	PenaltyAmount           *big.Int `json:"penalty_amount"`
+	RplSlashedMinipoolCount int      `json:"rpl_slashed_minipool_count"`
+	RplSlashedAmount        *big.Int `json:"rpl_slashed_amount"`
+	RplSlashedEthValue      *big.Int `json:"rpl_slashed_eth_value"`
+
+	// Slashings that couldn't be attributed to a minipool
+	UnattributedRplSlashings []RplSlashing `json:"unattributed_rpl_slashings"`
+}
+
+// RPLSlashed event data
+type rplSlashedEvent struct {
+	Node     common.Address
+	Amount   *big.Int
+	EthValue *big.Int
+	Time     *big.Int
+}
+
+// Get the network's penalty settings
+func (details *NetworkDetails) GetPenaltySettings() PenaltySettings {
+	return PenaltySettings{
+		Threshold: details.PenaltyThreshold,
+		PerRate:   details.PenaltyPerRate,
+		MaxRate:   details.PenaltyMaxRate,
+	}
+}
+
+// Get the penalty account of a minipool.
+// The penalty amount is calculated for an exit with the given balance; if it's nil, the minipool's deposit balances (an exit with no rewards) are used.
+func NewMinipoolPenaltyAccount(details *NativeMinipoolDetails, settings PenaltySettings, exitBalance *big.Int, slashings []RplSlashing) MinipoolPenaltyAccount {
+	if exitBalance == nil {
+		exitBalance = big.NewInt(0).Add(details.NodeDepositBalance, details.UserDepositBalance)
+	}
+
+	params := details.GetDistributionParameters(settings.MaxRate)
+	penaltyRate := params.PenaltyRate
+	withPenalty := params.CalculateNodeShare(exitBalance)
+	params.PenaltyRate = big.NewInt(0)
+	withoutPenalty := params.CalculateNodeShare(exitBalance)
+
+	account := MinipoolPenaltyAccount{
+		MinipoolAddress:         details.MinipoolAddress,
+		PenaltyCount:            details.PenaltyCount.Uint64(),
+		PenaltyRate:             penaltyRate,
+		ExitBalance:             exitBalance,
+		NodeShareWithoutPenalty: withoutPenalty,
+		NodeShareWithPenalty:    withPenalty,
+		PenaltyAmount:           big.NewInt(0).Sub(withoutPenalty, withPenalty),
+		RplSlashed:              details.Slashed,
+		RplSlashings:            []RplSlashing{},
+	}
+	for _, slashing := range slashings {
+		if slashing.MinipoolAddress == details.MinipoolAddress {
+			account.RplSlashings = append(account.RplSlashings, slashing)
+		}
+	}
+	return account
+}
+
+// Get the penalty account of a node from its view.
+// exitBalances optionally provides the expected exit balance of each minipool, and slashings are the node's RPL slashings (see GetNodeRplSlashings).
+func NewNodePenaltyAccount(view *NodeView, network *NetworkDetails, exitBalances map[common.Address]*big.Int, slashings []RplSlashing) NodePenaltyAccount {
+	account := NodePenaltyAccount{
+		NodeAddress:              view.Node.NodeAddress,
+		Settings:                 network.GetPenaltySettings(),
+		Minipools:                make([]MinipoolPenaltyAccount, len(view.Minipools)),
+		PenaltyAmount:            big.NewInt(0),
+		RplSlashedAmount:         big.NewInt(0),
+		RplSlashedEthValue:       big.NewInt(0),
+		UnattributedRplSlashings: []RplSlashing{},
+	}
+
+	for i := range view.Minipools {
+		mpd := &view.Minipools[i]
+		mpAccount := NewMinipoolPenaltyAccount(mpd, account.Settings, exitBalances[mpd.MinipoolAddress], slashings)
+		account.Minipools[i] = mpAccount
+		account.PenaltyCount += mpAccount.PenaltyCount
+		if mpAccount.PenaltyRate.Sign() > 0 {
+			account.PenalisedMinipoolCount++
+		}
+		account.PenaltyAmount.Add(account.PenaltyAmount, mpAccount.PenaltyAmount)
+		if mpAccount.RplSlashed {
+			account.RplSlashedMinipoolCount++
+		}
+	}
+
+	for _, slashing := range slashings {
+		account.RplSlashedAmount.Add(account.RplSlashedAmount, slashing.Amount)
+		account.RplSlashedEthValue.Add(account.RplSlashedEthValue, slashing.EthValue)
+		if slashing.MinipoolAddress == (common.Address{}) {
+			account.UnattributedRplSlashings = append(account.UnattributedRplSlashings, slashing)
+		}
+	}
+	return account
+}
+
+// Get a node's RPL slashings from the RPLSlashed events of every rocketNodeStaking deployment.
+// Each slashing is attributed to the minipool in minipoolAddresses that emitted events in the same transaction, if there is one.
+func GetNodeRplSlashings(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address, minipoolAddresses []common.Address, startBlock *big.Int, intervalSize *big.Int) ([]RplSlashing, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	event, exists := contracts.RocketNodeStaking.ABI.Events["RPLSlashed"]
+	if !exists {
+		return nil, fmt.Errorf("rocketNodeStaking does not have an RPLSlashed event")
+	}
+	query := eth.FilterQuery{
+		FromBlock: startBlock,
+		ToBlock:   contracts.ElBlockNumber,
+		Topics:    [][]common.Hash{{event.ID}, {common.BytesToHash(nodeAddress.Bytes())}},
+	}
+	logs, err := eth.FilterContractLogs(rp, "rocketNodeStaking", query, intervalSize, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error getting RPL slashing events for node %s: %w", nodeAddress.Hex(), err)
+	}
+
+	slashings := make([]RplSlashing, len(logs))
+	for i, log := range logs {
+		slashed := new(rplSlashedEvent)
+		if err := contracts.RocketNodeStaking.Contract.UnpackLog(slashed, "RPLSlashed", log); err != nil {
+			return nil, fmt.Errorf("error unpacking RPL slashing event: %w", err)
+		}
+		slashing := RplSlashing{
+			NodeAddress: nodeAddress,
+			Amount:      slashed.Amount,
+			EthValue:    slashed.EthValue,
+			Time:        convertToTime(slashed.Time),
+			TxHash:      log.TxHash,
+			BlockNumber: log.BlockNumber,
+		}
+
+		// Find the minipool that triggered the slashing
+		if len(minipoolAddresses) > 0 {
+			blockHash := log.BlockHash
+			minipoolLogs, err := rp.Client.FilterLogs(context.Background(), ethereum.FilterQuery{
+				BlockHash: &blockHash,
+				Addresses: minipoolAddresses,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error getting minipool events for block %d: %w", log.BlockNumber, err)
+			}
+			for _, minipoolLog := range minipoolLogs {
+				if minipoolLog.TxHash == log.TxHash {
+					slashing.MinipoolAddress = minipoolLog.Address
+					break
+				}
+			}
+		}
+		slashings[i] = slashing
+	}
+	return slashings, nil
+}
@@ -0,0 +1,45 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+func TestNewMinipoolPenaltyAccount(t *testing.T) {
+	// An 8 ETH minipool exiting with its 32 ETH deposit, so the node's share is its 8 ETH bond
+	tests := []struct {
+		name                string
+		penaltyRate         float64
+		maxRate             *big.Int
+		expectedPenaltyRate float64
+		expectedPenalty     float64
+	}{
+		{name: "No penalty", penaltyRate: 0, maxRate: eth.EthToWei(0.8), expectedPenaltyRate: 0, expectedPenalty: 0},
+		{name: "Under the maximum", penaltyRate: 0.5, maxRate: eth.EthToWei(0.8), expectedPenaltyRate: 0.5, expectedPenalty: 4},
+		{name: "Over the maximum", penaltyRate: 1, maxRate: eth.EthToWei(0.8), expectedPenaltyRate: 0.8, expectedPenalty: 6.4},
+		{name: "No maximum", penaltyRate: 1, expectedPenaltyRate: 1, expectedPenalty: 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			details := &NativeMinipoolDetails{
+				Version:            3,
+				NodeFee:            eth.EthToWei(0.14),
+				NodeDepositBalance: eth.EthToWei(8),
+				UserDepositBalance: eth.EthToWei(24),
+				NodeRefundBalance:  big.NewInt(0),
+				PenaltyCount:       big.NewInt(0),
+				PenaltyRate:        eth.EthToWei(test.penaltyRate),
+			}
+			account := NewMinipoolPenaltyAccount(details, PenaltySettings{MaxRate: test.maxRate}, nil, nil)
+			if rate := eth.WeiToEth(account.PenaltyRate); rate != test.expectedPenaltyRate {
+				t.Errorf("expected a penalty rate of %f but got %f", test.expectedPenaltyRate, rate)
+			}
+			if penalty := eth.WeiToEth(account.PenaltyAmount); penalty != test.expectedPenalty {
+				t.Errorf("expected a penalty of %f but got %f", test.expectedPenalty, penalty)
+			}
+		})
+	}
+}
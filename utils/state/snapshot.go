@@ -0,0 +1,196 @@
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/dao/protocol"
+)
+
+// The current snapshot schema version; this must be bumped whenever the serialized layout of the state changes
+const NetworkStateSnapshotSchemaVersion uint32 = 3
+
+// Prefix for snapshots in the binary encoding
+var snapshotMagic = []byte("RPNS")
+
+// A network state with the metadata needed to load it later without an RPC connection
+type NetworkStateSnapshot struct {
+	SchemaVersion    uint32        `json:"schema_version"`
+	Network          string        `json:"network"`
+	ElBlockNumber    uint64        `json:"el_block_number"`
+	ElBlockTimestamp time.Time     `json:"el_block_timestamp"`
+	ProtocolVersion  string        `json:"protocol_version"`
+	State            *NetworkState `json:"state"`
+}
+
+// The serialized fields of a network state; the lookup maps are rebuilt on load instead of being stored
+type networkStateData struct {
+	ElBlockNumber              uint64
+	ElBlockTimestamp           time.Time
+	ProtocolVersion            string
+	Sections                   NetworkStateSection
+	NetworkDetails             *NetworkDetails
+	NodeDetails                []NativeNodeDetails
+	MinipoolDetails            []NativeMinipoolDetails
+	OracleDaoMemberDetails     []OracleDaoMemberDetails
+	ProtocolDaoProposalDetails []protocol.ProtocolDaoProposalDetails
+	NodeVotingPower            map[common.Address]*big.Int
+	TotalEffectiveRPLStake     *big.Int
+}
+
+// Create a snapshot of a network state for the provided network (e.g. "mainnet")
+func NewNetworkStateSnapshot(state *NetworkState, network string) *NetworkStateSnapshot {
+	return &NetworkStateSnapshot{
+		SchemaVersion:    NetworkStateSnapshotSchemaVersion,
+		Network:          network,
+		ElBlockNumber:    state.ElBlockNumber,
+		ElBlockTimestamp: state.ElBlockTimestamp,
+		ProtocolVersion:  state.ProtocolVersion,
+		State:            state,
+	}
+}
+
+// Write the snapshot as JSON
+func (s *NetworkStateSnapshot) WriteJSON(w io.Writer) error {
+	err := json.NewEncoder(w).Encode(s)
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot as JSON: %w", err)
+	}
+	return nil
+}
+
+// Write the snapshot in the compact binary encoding: the magic prefix and schema version, followed by the gzipped gob encoding
+func (s *NetworkStateSnapshot) WriteBinary(w io.Writer) error {
+	if _, err := w.Write(snapshotMagic); err != nil {
+		return fmt.Errorf("error writing snapshot header: %w", err)
+	}
+	if err := binary.Write(w, binary.BigEndian, s.SchemaVersion); err != nil {
+		return fmt.Errorf("error writing snapshot schema version: %w", err)
+	}
+
+	zw := gzip.NewWriter(w)
+	if err := gob.NewEncoder(zw).Encode(s); err != nil {
+		return fmt.Errorf("error encoding snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("error compressing snapshot: %w", err)
+	}
+	return nil
+}
+
+// Read a snapshot written by WriteJSON or WriteBinary; the encoding is detected automatically
+func ReadNetworkStateSnapshot(r io.Reader) (*NetworkStateSnapshot, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(len(snapshotMagic))
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot header: %w", err)
+	}
+
+	snapshot := &NetworkStateSnapshot{}
+	if bytes.Equal(prefix, snapshotMagic) {
+		// Binary
+		if _, err := br.Discard(len(snapshotMagic)); err != nil {
+			return nil, fmt.Errorf("error reading snapshot header: %w", err)
+		}
+		var schemaVersion uint32
+		if err := binary.Read(br, binary.BigEndian, &schemaVersion); err != nil {
+			return nil, fmt.Errorf("error reading snapshot schema version: %w", err)
+		}
+		if err := checkSnapshotSchemaVersion(schemaVersion); err != nil {
+			return nil, err
+		}
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing snapshot: %w", err)
+		}
+		defer zr.Close()
+		if err := gob.NewDecoder(zr).Decode(snapshot); err != nil {
+			return nil, fmt.Errorf("error decoding snapshot: %w", err)
+		}
+	} else {
+		// JSON
+		if err := json.NewDecoder(br).Decode(snapshot); err != nil {
+			return nil, fmt.Errorf("error decoding snapshot JSON: %w", err)
+		}
+	}
+
+	// Validate the contents
+	if err := checkSnapshotSchemaVersion(snapshot.SchemaVersion); err != nil {
+		return nil, err
+	}
+	if snapshot.State == nil {
+		return nil, fmt.Errorf("snapshot does not contain a network state")
+	}
+	if snapshot.State.ElBlockNumber != snapshot.ElBlockNumber {
+		return nil, fmt.Errorf("snapshot is for block %d but its state is for block %d", snapshot.ElBlockNumber, snapshot.State.ElBlockNumber)
+	}
+	snapshot.State.updateIndices()
+
+	return snapshot, nil
+}
+
+// Make sure a snapshot's schema can be read by this version of the library.
+// Older schemas are missing fields that later versions rely on, so they have to be reloaded from the chain rather than read.
+func checkSnapshotSchemaVersion(schemaVersion uint32) error {
+	if schemaVersion < NetworkStateSnapshotSchemaVersion {
+		return fmt.Errorf("snapshot schema version %d is no longer supported; reload the state to create a version %d snapshot", schemaVersion, NetworkStateSnapshotSchemaVersion)
+	}
+	if schemaVersion > NetworkStateSnapshotSchemaVersion {
+		return fmt.Errorf("unsupported snapshot schema version %d (supported up to %d)", schemaVersion, NetworkStateSnapshotSchemaVersion)
+	}
+	return nil
+}
+
+// Gob encoding that leaves out the lookup maps
+func (s *NetworkState) GobEncode() ([]byte, error) {
+	data := networkStateData{
+		ElBlockNumber:              s.ElBlockNumber,
+		ElBlockTimestamp:           s.ElBlockTimestamp,
+		ProtocolVersion:            s.ProtocolVersion,
+		Sections:                   s.Sections,
+		NetworkDetails:             s.NetworkDetails,
+		NodeDetails:                s.NodeDetails,
+		MinipoolDetails:            s.MinipoolDetails,
+		OracleDaoMemberDetails:     s.OracleDaoMemberDetails,
+		ProtocolDaoProposalDetails: s.ProtocolDaoProposalDetails,
+		NodeVotingPower:            s.NodeVotingPower,
+		TotalEffectiveRPLStake:     s.TotalEffectiveRPLStake,
+	}
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(data); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Gob decoding that rebuilds the lookup maps
+func (s *NetworkState) GobDecode(encoded []byte) error {
+	var data networkStateData
+	if err := gob.NewDecoder(bytes.NewReader(encoded)).Decode(&data); err != nil {
+		return err
+	}
+	*s = NetworkState{
+		ElBlockNumber:              data.ElBlockNumber,
+		ElBlockTimestamp:           data.ElBlockTimestamp,
+		ProtocolVersion:            data.ProtocolVersion,
+		Sections:                   data.Sections,
+		NetworkDetails:             data.NetworkDetails,
+		NodeDetails:                data.NodeDetails,
+		MinipoolDetails:            data.MinipoolDetails,
+		OracleDaoMemberDetails:     data.OracleDaoMemberDetails,
+		ProtocolDaoProposalDetails: data.ProtocolDaoProposalDetails,
+		NodeVotingPower:            data.NodeVotingPower,
+		TotalEffectiveRPLStake:     data.TotalEffectiveRPLStake,
+	}
+	s.updateIndices()
+	return nil
+}
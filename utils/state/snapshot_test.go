@@ -0,0 +1,115 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+func TestNetworkStateSnapshotRoundTrip(t *testing.T) {
+	nodeAddress := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	minipoolAddress := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	state := &NetworkState{
+		ElBlockNumber:    18000000,
+		ElBlockTimestamp: time.Unix(1693000000, 0).UTC(),
+		ProtocolVersion:  "1.2.0",
+		Sections:         NetworkStateSection_NetworkDetails | NetworkStateSection_Nodes | NetworkStateSection_Minipools,
+		NetworkDetails: &NetworkDetails{
+			RplPrice:                 big.NewInt(7000000000000000),
+			PenaltyThreshold:         big.NewInt(510000000000000000),
+			BondReductionWindowStart: 12 * time.Hour,
+		},
+		NodeDetails: []NativeNodeDetails{{
+			Exists:           true,
+			NodeAddress:      nodeAddress,
+			TimezoneLocation: "Europe/Berlin",
+			RplStake:         big.NewInt(1000),
+		}},
+		MinipoolDetails: []NativeMinipoolDetails{{
+			Exists:          true,
+			MinipoolAddress: minipoolAddress,
+			NodeAddress:     nodeAddress,
+			Pubkey:          types.BytesToValidatorPubkey(bytes.Repeat([]byte{0xab}, types.ValidatorPubkeyLength)),
+			Status:          types.Staking,
+			Balance:         big.NewInt(32),
+		}},
+		NodeVotingPower:        map[common.Address]*big.Int{nodeAddress: big.NewInt(42)},
+		TotalEffectiveRPLStake: big.NewInt(1000),
+	}
+	state.updateIndices()
+
+	tests := []struct {
+		name  string
+		write func(*NetworkStateSnapshot, *bytes.Buffer) error
+	}{
+		{
+			name:  "json",
+			write: func(s *NetworkStateSnapshot, b *bytes.Buffer) error { return s.WriteJSON(b) },
+		},
+		{
+			name:  "binary",
+			write: func(s *NetworkStateSnapshot, b *bytes.Buffer) error { return s.WriteBinary(b) },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := test.write(NewNetworkStateSnapshot(state, "mainnet"), &buffer); err != nil {
+				t.Fatalf("error writing snapshot: %v", err)
+			}
+			snapshot, err := ReadNetworkStateSnapshot(&buffer)
+			if err != nil {
+				t.Fatalf("error reading snapshot: %v", err)
+			}
+			if snapshot.Network != "mainnet" || snapshot.SchemaVersion != NetworkStateSnapshotSchemaVersion {
+				t.Errorf("unexpected metadata: network %s, schema version %d", snapshot.Network, snapshot.SchemaVersion)
+			}
+			if !reflect.DeepEqual(snapshot.State, state) {
+				t.Errorf("state changed in round trip:\nwant %+v\ngot  %+v", state, snapshot.State)
+			}
+			if snapshot.State.GetMinipool(minipoolAddress) != &snapshot.State.MinipoolDetails[0] {
+				t.Errorf("minipool index was not rebuilt")
+			}
+		})
+	}
+}
+
+func TestReadNetworkStateSnapshotSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name          string
+		schemaVersion uint32
+		err           string
+	}{
+		{name: "current", schemaVersion: NetworkStateSnapshotSchemaVersion},
+		{name: "older", schemaVersion: NetworkStateSnapshotSchemaVersion - 1, err: "no longer supported"},
+		{name: "zero", schemaVersion: 0, err: "no longer supported"},
+		{name: "newer", schemaVersion: NetworkStateSnapshotSchemaVersion + 1, err: "unsupported"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			snapshot := NewNetworkStateSnapshot(&NetworkState{}, "mainnet")
+			snapshot.SchemaVersion = test.schemaVersion
+			if err := snapshot.WriteBinary(&buffer); err != nil {
+				t.Fatalf("error writing snapshot: %v", err)
+			}
+
+			_, err := ReadNetworkStateSnapshot(&buffer)
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("expected error containing '%s', got %v", test.err, err)
+			}
+		})
+	}
+}
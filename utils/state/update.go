@@ -0,0 +1,524 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/eth"
+)
+
+// The number of minipool addresses to filter on in each log request
+const minipoolLogAddressBatchSize int = 1000
+
+// The nodes and minipools that need to be re-fetched during a state update
+type stateUpdateTargets struct {
+	nodes              map[common.Address]bool
+	minipools          map[common.Address]bool
+	newNodes           []common.Address
+	newMinipools       []common.Address
+	destroyedMinipools map[common.Address]bool
+}
+
+// Update the state to the block of the provided contracts.
+// The Rocket Pool events emitted between the two blocks determine which nodes and minipools changed, and only those are re-fetched.
+// ETH balances change without emitting events, so they're refreshed for every node, distributor and minipool; any other value
+// that changes without an event is only picked up by a full reload.
+func (s *NetworkState) Update(rp *rocketpool.RocketPool, contracts *NetworkContracts, intervalSize *big.Int) error {
+	targetBlock := contracts.ElBlockNumber.Uint64()
+	if targetBlock <= s.ElBlockNumber {
+		return fmt.Errorf("target block %d is not after the state's block %d", targetBlock, s.ElBlockNumber)
+	}
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+	fromBlock := big.NewInt(0).SetUint64(s.ElBlockNumber + 1)
+
+	// Find the nodes and minipools touched by events
+	targets, err := s.getUpdateTargets(rp, contracts, fromBlock, contracts.ElBlockNumber, intervalSize)
+	if err != nil {
+		return fmt.Errorf("error getting state update targets: %w", err)
+	}
+
+	// The RPL price and collateral settings affect the stake limits of every node
+	previousLimits, err := getStakeLimitSettings(contracts, &bind.CallOpts{BlockNumber: big.NewInt(0).SetUint64(s.ElBlockNumber)})
+	if err != nil {
+		return fmt.Errorf("error getting previous stake limit settings: %w", err)
+	}
+	currentLimits, err := getStakeLimitSettings(contracts, opts)
+	if err != nil {
+		return fmt.Errorf("error getting stake limit settings: %w", err)
+	}
+	stakeLimitsChanged := false
+	for i := range currentLimits {
+		if previousLimits[i].Cmp(currentLimits[i]) != 0 {
+			stakeLimitsChanged = true
+		}
+	}
+
+	// Build the update in a copy so a failure partway through leaves the state at its old block
+	updated := *s
+	updated.NodeDetails = append([]NativeNodeDetails(nil), s.NodeDetails...)
+	updated.MinipoolDetails = append([]NativeMinipoolDetails(nil), s.MinipoolDetails...)
+	if s.NodeVotingPower != nil {
+		updated.NodeVotingPower = make(map[common.Address]*big.Int, len(s.NodeVotingPower))
+		for address, power := range s.NodeVotingPower {
+			updated.NodeVotingPower[address] = power
+		}
+	}
+
+	if s.Sections.Has(NetworkStateSection_NetworkDetails) {
+		updated.NetworkDetails, err = NewNetworkDetails(rp, contracts)
+		if err != nil {
+			return fmt.Errorf("error getting network details: %w", err)
+		}
+	}
+
+	if s.Sections.Has(NetworkStateSection_Minipools) {
+		err = updated.updateMinipools(rp, contracts, targets, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Sections.Has(NetworkStateSection_Nodes) {
+		err = updated.updateNodes(rp, contracts, targets, stakeLimitsChanged, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Sections.Has(NetworkStateSection_OracleDao) {
+		updated.OracleDaoMemberDetails, err = GetAllOracleDaoMemberDetails(rp, contracts)
+		if err != nil {
+			return fmt.Errorf("error getting Oracle DAO details: %w", err)
+		}
+	}
+
+	if s.Sections.Has(NetworkStateSection_ProtocolDao) {
+		updated.ProtocolDaoProposalDetails, err = GetAllProtocolDaoProposalDetails(rp, contracts)
+		if err != nil {
+			return fmt.Errorf("error getting Protocol DAO proposal details: %w", err)
+		}
+	}
+
+	if s.Sections.Has(NetworkStateSection_VotingPower) {
+		err = updated.updateVotingPower(rp, contracts, targets, stakeLimitsChanged, opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	updated.ElBlockNumber = targetBlock
+	updated.ProtocolVersion = contracts.Version.String()
+	err = updated.loadBlockTimestamp(rp, contracts)
+	if err != nil {
+		return err
+	}
+	updated.updateIndices()
+	err = updated.calculateDerivedFields()
+	if err != nil {
+		return fmt.Errorf("error calculating derived fields: %w", err)
+	}
+
+	*s = updated
+	return nil
+}
+
+// Read the Rocket Pool events in the block range and work out which nodes and minipools they affect
+func (s *NetworkState) getUpdateTargets(rp *rocketpool.RocketPool, contracts *NetworkContracts, fromBlock *big.Int, toBlock *big.Int, intervalSize *big.Int) (*stateUpdateTargets, error) {
+	targets := &stateUpdateTargets{
+		nodes:              map[common.Address]bool{},
+		minipools:          map[common.Address]bool{},
+		destroyedMinipools: map[common.Address]bool{},
+	}
+
+	// Map distributors back to their nodes, since the distributor factory only reports the proxy address
+	distributorOwners := map[common.Address]common.Address{}
+	for _, node := range s.NodeDetails {
+		distributorOwners[node.FeeDistributorAddress] = node.NodeAddress
+	}
+
+	// Events emitted by the network contracts, at every address they've been deployed at
+	sources := map[string]*rocketpool.Contract{
+		"rocketNodeManager":            contracts.RocketNodeManager,
+		"rocketNodeStaking":            contracts.RocketNodeStaking,
+		"rocketNodeDeposit":            contracts.RocketNodeDeposit,
+		"rocketNodeDistributorFactory": contracts.RocketNodeDistributorFactory,
+		"rocketMinipoolManager":        contracts.RocketMinipoolManager,
+		"rocketMinipoolBondReducer":    contracts.RocketMinipoolBondReducer,
+		"rocketNetworkVoting":          contracts.RocketNetworkVoting,
+		"rocketStorage":                contracts.RocketStorage,
+		"rocketTokenRPL":               contracts.RocketTokenRPL,
+		"rocketTokenRPLFixedSupply":    contracts.RocketTokenRPLFixedSupply,
+		"rocketTokenRETH":              contracts.RocketTokenRETH,
+	}
+	networkEvents := map[common.Hash]abi.Event{}
+	for _, contract := range sources {
+		for _, event := range contract.ABI.Events {
+			networkEvents[event.ID] = event
+		}
+	}
+	addresses, err := getContractDeployments(rp, sources, intervalSize, toBlock)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network contract deployments: %w", err)
+	}
+	logs, err := eth.GetLogs(rp, addresses, nil, intervalSize, fromBlock, toBlock, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting network contract logs: %w", err)
+	}
+	err = s.addNetworkLogTargets(targets, logs, networkEvents, distributorOwners)
+	if err != nil {
+		return nil, err
+	}
+
+	// Events emitted by the minipools themselves, from the known minipools and the ones created in the range
+	minipoolEvents := map[common.Hash]abi.Event{}
+	for _, version := range []uint8{2, 3} {
+		mp, err := minipool.NewMinipoolFromVersion(rp, common.Address{}, version, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error creating v%d minipool binding: %w", version, err)
+		}
+		for _, event := range mp.GetContract().ABI.Events {
+			minipoolEvents[event.ID] = event
+		}
+	}
+	topics := make([]common.Hash, 0, len(minipoolEvents))
+	for id := range minipoolEvents {
+		topics = append(topics, id)
+	}
+	minipoolAddresses := make([]common.Address, 0, len(s.MinipoolDetails)+len(targets.newMinipools))
+	for _, mpd := range s.MinipoolDetails {
+		minipoolAddresses = append(minipoolAddresses, mpd.MinipoolAddress)
+	}
+	minipoolAddresses = append(minipoolAddresses, targets.newMinipools...)
+	for i := 0; i < len(minipoolAddresses); i += minipoolLogAddressBatchSize {
+		max := i + minipoolLogAddressBatchSize
+		if max > len(minipoolAddresses) {
+			max = len(minipoolAddresses)
+		}
+		logs, err := eth.GetLogs(rp, minipoolAddresses[i:max], [][]common.Hash{topics}, intervalSize, fromBlock, toBlock, nil)
+		if err != nil {
+			return nil, fmt.Errorf("error getting minipool logs: %w", err)
+		}
+		err = s.addMinipoolLogTargets(targets, logs, minipoolEvents, distributorOwners)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	targets.removeShortLivedMinipools()
+	return targets, nil
+}
+
+// Get every address the provided contracts have been deployed at, up to the given block, the same way eth.FilterContractLogs does
+func getContractDeployments(rp *rocketpool.RocketPool, sources map[string]*rocketpool.Contract, intervalSize *big.Int, toBlock *big.Int) ([]common.Address, error) {
+	rocketDaoNodeTrustedUpgrade, err := rp.GetContract("rocketDAONodeTrustedUpgrade", &bind.CallOpts{BlockNumber: toBlock})
+	if err != nil {
+		return nil, err
+	}
+
+	// The current addresses, and the addresses that were replaced by an upgrade
+	addresses := make([]common.Address, 0, len(sources))
+	nameHashes := make([]common.Hash, 0, len(sources))
+	for name, contract := range sources {
+		addresses = append(addresses, *contract.Address)
+		nameHashes = append(nameHashes, crypto.Keccak256Hash([]byte(name)))
+	}
+	topicFilter := [][]common.Hash{{rocketDaoNodeTrustedUpgrade.ABI.Events["ContractUpgraded"].ID}, nameHashes}
+	logs, err := eth.GetLogs(rp, []common.Address{*rocketDaoNodeTrustedUpgrade.Address}, topicFilter, intervalSize, nil, toBlock, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, log := range logs {
+		addresses = append(addresses, common.HexToAddress(log.Topics[2].Hex()))
+	}
+	return addresses, nil
+}
+
+// Flag the nodes and minipools affected by events from the network contracts
+func (s *NetworkState) addNetworkLogTargets(targets *stateUpdateTargets, logs []ethtypes.Log, networkEvents map[common.Hash]abi.Event, distributorOwners map[common.Address]common.Address) error {
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+
+		// Older deployments can emit events that aren't in the current ABIs, so fall back to the indexed addresses
+		event, exists := networkEvents[log.Topics[0]]
+		if !exists {
+			for _, topic := range log.Topics[1:] {
+				s.addUpdateTarget(targets, common.BytesToAddress(topic.Bytes()), distributorOwners)
+			}
+			continue
+		}
+		args, err := getEventAddressArgs(event, log)
+		if err != nil {
+			return fmt.Errorf("error decoding %s event in tx %s: %w", event.Name, log.TxHash.Hex(), err)
+		}
+
+		switch event.Name {
+		case "NodeRegistered":
+			node := args["node"]
+			if !s.hasNode(node) {
+				targets.newNodes = append(targets.newNodes, node)
+			}
+		case "MinipoolCreated":
+			mp := args["minipool"]
+			if s.MinipoolDetailsByAddress[mp] == nil {
+				targets.newMinipools = append(targets.newMinipools, mp)
+			}
+		case "MinipoolDestroyed":
+			targets.destroyedMinipools[args["minipool"]] = true
+		}
+
+		for _, address := range args {
+			s.addUpdateTarget(targets, address, distributorOwners)
+		}
+	}
+	return nil
+}
+
+// Flag the minipools that emitted events, and the nodes and minipools those events refer to
+func (s *NetworkState) addMinipoolLogTargets(targets *stateUpdateTargets, logs []ethtypes.Log, minipoolEvents map[common.Hash]abi.Event, distributorOwners map[common.Address]common.Address) error {
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		event, exists := minipoolEvents[log.Topics[0]]
+		if !exists {
+			continue
+		}
+		s.addUpdateTarget(targets, log.Address, distributorOwners)
+		args, err := getEventAddressArgs(event, log)
+		if err != nil {
+			return fmt.Errorf("error decoding minipool event in tx %s: %w", log.TxHash.Hex(), err)
+		}
+		for _, address := range args {
+			s.addUpdateTarget(targets, address, distributorOwners)
+		}
+	}
+	return nil
+}
+
+// Ignore minipools that were created and destroyed within the range
+func (t *stateUpdateTargets) removeShortLivedMinipools() {
+	if len(t.destroyedMinipools) == 0 {
+		return
+	}
+	created := make([]common.Address, 0, len(t.newMinipools))
+	for _, address := range t.newMinipools {
+		if !t.destroyedMinipools[address] {
+			created = append(created, address)
+		}
+	}
+	t.newMinipools = created
+}
+
+// Get the RPL price and the minimum and maximum per-minipool stake settings
+func getStakeLimitSettings(contracts *NetworkContracts, opts *bind.CallOpts) ([]*big.Int, error) {
+	settings := make([]*big.Int, 3)
+	contracts.Multicaller.AddCall(contracts.RocketNetworkPrices, &settings[0], "getRPLPrice")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNode, &settings[1], "getMinimumPerMinipoolStake")
+	contracts.Multicaller.AddCall(contracts.RocketDAOProtocolSettingsNode, &settings[2], "getMaximumPerMinipoolStake")
+	_, err := contracts.Multicaller.FlexibleCall(true, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error executing multicall: %w", err)
+	}
+	return settings, nil
+}
+
+// Flag an address seen in an event as needing an update if it belongs to a known node, minipool or distributor
+func (s *NetworkState) addUpdateTarget(targets *stateUpdateTargets, address common.Address, distributorOwners map[common.Address]common.Address) {
+	if mpd := s.MinipoolDetailsByAddress[address]; mpd != nil {
+		targets.minipools[address] = true
+		targets.nodes[mpd.NodeAddress] = true
+		return
+	}
+	if s.hasNode(address) {
+		targets.nodes[address] = true
+		return
+	}
+	if owner, exists := distributorOwners[address]; exists {
+		targets.nodes[owner] = true
+	}
+}
+
+// Check if the node is known to the state
+func (s *NetworkState) hasNode(address common.Address) bool {
+	if _, exists := s.NodeDetailsByAddress[address]; exists {
+		return true
+	}
+	_, exists := s.NodeVotingPower[address]
+	return exists
+}
+
+// Refresh the minipool balances and re-fetch the minipools that changed
+func (s *NetworkState) updateMinipools(rp *rocketpool.RocketPool, contracts *NetworkContracts, targets *stateUpdateTargets, opts *bind.CallOpts) error {
+	// Drop destroyed minipools
+	if len(targets.destroyedMinipools) > 0 {
+		remaining := make([]NativeMinipoolDetails, 0, len(s.MinipoolDetails))
+		for _, mpd := range s.MinipoolDetails {
+			if !targets.destroyedMinipools[mpd.MinipoolAddress] {
+				remaining = append(remaining, mpd)
+			}
+		}
+		s.MinipoolDetails = remaining
+	}
+
+	// Minipool balances change without events, and the shares depend on them
+	addresses := make([]common.Address, len(s.MinipoolDetails))
+	for i, mpd := range s.MinipoolDetails {
+		addresses[i] = mpd.MinipoolAddress
+	}
+	balances, err := contracts.BalanceBatcher.GetEthBalances(addresses, opts)
+	if err != nil {
+		return fmt.Errorf("error getting minipool balances: %w", err)
+	}
+	for i, mpd := range s.MinipoolDetails {
+		if mpd.Balance.Cmp(balances[i]) != 0 {
+			targets.minipools[mpd.MinipoolAddress] = true
+			targets.nodes[mpd.NodeAddress] = true
+		}
+	}
+
+	// Re-fetch the existing minipools that changed, then the new ones
+	indices := make([]int, 0, len(targets.minipools))
+	refresh := make([]common.Address, 0, len(targets.minipools)+len(targets.newMinipools))
+	for i, mpd := range s.MinipoolDetails {
+		if targets.minipools[mpd.MinipoolAddress] {
+			indices = append(indices, i)
+			refresh = append(refresh, mpd.MinipoolAddress)
+		}
+	}
+	refresh = append(refresh, targets.newMinipools...)
+	if len(refresh) == 0 {
+		return nil
+	}
+
+	versions, err := getMinipoolVersionsFast(rp, contracts, refresh, opts)
+	if err != nil {
+		return fmt.Errorf("error getting minipool versions: %w", err)
+	}
+	details, err := getBulkMinipoolDetails(rp, contracts, refresh, versions, opts)
+	if err != nil {
+		return fmt.Errorf("error getting minipool details: %w", err)
+	}
+	for i, index := range indices {
+		s.MinipoolDetails[index] = details[i]
+	}
+	for _, mpd := range details[len(indices):] {
+		s.MinipoolDetails = append(s.MinipoolDetails, mpd)
+		targets.nodes[mpd.NodeAddress] = true
+	}
+
+	return nil
+}
+
+// Refresh the node and distributor balances and re-fetch the nodes that changed
+func (s *NetworkState) updateNodes(rp *rocketpool.RocketPool, contracts *NetworkContracts, targets *stateUpdateTargets, stakeLimitsChanged bool, opts *bind.CallOpts) error {
+	// Re-fetch the existing nodes that changed, then the new ones
+	indices := make([]int, 0, len(targets.nodes))
+	refresh := make([]common.Address, 0, len(targets.nodes)+len(targets.newNodes))
+	for i, node := range s.NodeDetails {
+		if stakeLimitsChanged || targets.nodes[node.NodeAddress] {
+			indices = append(indices, i)
+			refresh = append(refresh, node.NodeAddress)
+		}
+	}
+	refresh = append(refresh, targets.newNodes...)
+	if len(refresh) > 0 {
+		details, err := getBulkNodeDetails(rp, contracts, refresh, opts)
+		if err != nil {
+			return fmt.Errorf("error getting node details: %w", err)
+		}
+		for i, index := range indices {
+			s.NodeDetails[index] = details[i]
+		}
+		s.NodeDetails = append(s.NodeDetails, details[len(indices):]...)
+	}
+
+	// Node and distributor balances change without events
+	count := len(s.NodeDetails)
+	addresses := make([]common.Address, count)
+	distributorAddresses := make([]common.Address, count)
+	for i, node := range s.NodeDetails {
+		addresses[i] = node.NodeAddress
+		distributorAddresses[i] = node.FeeDistributorAddress
+	}
+	balances, err := contracts.BalanceBatcher.GetEthBalances(addresses, opts)
+	if err != nil {
+		return fmt.Errorf("error getting node balances: %w", err)
+	}
+	distributorBalances, err := contracts.BalanceBatcher.GetEthBalances(distributorAddresses, opts)
+	if err != nil {
+		return fmt.Errorf("error getting distributor balances: %w", err)
+	}
+	for i := range s.NodeDetails {
+		s.NodeDetails[i].BalanceETH = balances[i]
+		s.NodeDetails[i].DistributorBalance = distributorBalances[i]
+	}
+
+	return nil
+}
+
+// Re-fetch the voting power of the nodes that changed
+func (s *NetworkState) updateVotingPower(rp *rocketpool.RocketPool, contracts *NetworkContracts, targets *stateUpdateTargets, stakeLimitsChanged bool, opts *bind.CallOpts) error {
+	// Voting power depends on the RPL price, so every node needs to be reloaded when it changes
+	if stakeLimitsChanged {
+		return s.loadVotingPower(rp, contracts)
+	}
+
+	refresh := make([]common.Address, 0, len(targets.nodes)+len(targets.newNodes))
+	for address := range targets.nodes {
+		refresh = append(refresh, address)
+	}
+	refresh = append(refresh, targets.newNodes...)
+	powers, err := getNodeVotingPowerFast(rp, contracts, refresh, opts)
+	if err != nil {
+		return err
+	}
+	for i, address := range refresh {
+		s.NodeVotingPower[address] = powers[i]
+	}
+	return nil
+}
+
+// Get every address argument of an event log, keyed by argument name
+func getEventAddressArgs(event abi.Event, log ethtypes.Log) (map[string]common.Address, error) {
+	args := map[string]common.Address{}
+
+	// Indexed arguments are in the topics
+	topicIndex := 1
+	for _, input := range event.Inputs {
+		if !input.Indexed {
+			continue
+		}
+		if topicIndex >= len(log.Topics) {
+			return nil, fmt.Errorf("log has %d topics but event has more indexed arguments", len(log.Topics))
+		}
+		if input.Type.T == abi.AddressTy {
+			args[input.Name] = common.BytesToAddress(log.Topics[topicIndex].Bytes())
+		}
+		topicIndex++
+	}
+
+	// Everything else is in the data
+	values := map[string]interface{}{}
+	err := event.Inputs.NonIndexed().UnpackIntoMap(values, log.Data)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range values {
+		if address, ok := value.(common.Address); ok {
+			args[name] = address
+		}
+	}
+
+	return args, nil
+}
@@ -0,0 +1,155 @@
+package state
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	ethtypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// A subset of the network and minipool events, enough to build logs from
+const updateTestAbi = `[
+	{"type":"event","name":"NodeRegistered","inputs":[{"name":"node","type":"address","indexed":true},{"name":"time","type":"uint256","indexed":false}]},
+	{"type":"event","name":"MinipoolCreated","inputs":[{"name":"minipool","type":"address","indexed":true},{"name":"node","type":"address","indexed":true},{"name":"time","type":"uint256","indexed":false}]},
+	{"type":"event","name":"MinipoolDestroyed","inputs":[{"name":"minipool","type":"address","indexed":true},{"name":"node","type":"address","indexed":true},{"name":"time","type":"uint256","indexed":false}]},
+	{"type":"event","name":"RPLStaked","inputs":[{"name":"from","type":"address","indexed":true},{"name":"amount","type":"uint256","indexed":false},{"name":"time","type":"uint256","indexed":false}]},
+	{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true},{"name":"to","type":"address","indexed":false},{"name":"value","type":"uint256","indexed":false}]},
+	{"type":"event","name":"StatusUpdated","inputs":[{"name":"status","type":"uint8","indexed":false},{"name":"time","type":"uint256","indexed":false}]}
+]`
+
+func TestNetworkStateGetUpdateTargetsFromLogs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(updateTestAbi))
+	if err != nil {
+		t.Fatalf("error parsing ABI: %v", err)
+	}
+	events := map[common.Hash]abi.Event{}
+	for _, event := range parsed.Events {
+		events[event.ID] = event
+	}
+
+	// Build a log for an event, with the indexed arguments in the topics and the rest in the data
+	makeLog := func(address common.Address, name string, indexed []common.Address, values ...interface{}) ethtypes.Log {
+		event := parsed.Events[name]
+		topics := []common.Hash{event.ID}
+		for _, arg := range indexed {
+			topics = append(topics, common.BytesToHash(arg.Bytes()))
+		}
+		data, err := event.Inputs.NonIndexed().Pack(values...)
+		if err != nil {
+			t.Fatalf("error packing %s: %v", name, err)
+		}
+		return ethtypes.Log{Address: address, Topics: topics, Data: data}
+	}
+
+	knownNode := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	otherNode := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	newNode := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	knownMinipool := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	newMinipool := common.HexToAddress("0x5555555555555555555555555555555555555555")
+	shortLivedMinipool := common.HexToAddress("0x6666666666666666666666666666666666666666")
+	distributor := common.HexToAddress("0x7777777777777777777777777777777777777777")
+	stranger := common.HexToAddress("0x8888888888888888888888888888888888888888")
+	contract := common.HexToAddress("0x9999999999999999999999999999999999999999")
+	time := big.NewInt(1700000000)
+
+	// Events from the network contracts
+	networkLogs := []ethtypes.Log{
+		makeLog(contract, "NodeRegistered", []common.Address{newNode}, time),
+		makeLog(contract, "NodeRegistered", []common.Address{knownNode}, time),
+		makeLog(contract, "MinipoolCreated", []common.Address{newMinipool, newNode}, time),
+		makeLog(contract, "MinipoolCreated", []common.Address{shortLivedMinipool, newNode}, time),
+		makeLog(contract, "MinipoolDestroyed", []common.Address{shortLivedMinipool, newNode}, time),
+		makeLog(contract, "Transfer", []common.Address{stranger}, distributor, big.NewInt(1)),
+		makeLog(contract, "RPLStaked", []common.Address{stranger}, big.NewInt(1), time),
+		{Address: contract},
+	}
+
+	// A legacy event that isn't in the current ABI
+	legacyStake := makeLog(contract, "RPLStaked", []common.Address{otherNode}, big.NewInt(1), time)
+	legacyStake.Topics[0] = crypto.Keccak256Hash([]byte("RPLStaked(address,uint256,uint256,uint256)"))
+	networkLogs = append(networkLogs, legacyStake)
+
+	// Events from the minipools themselves
+	minipoolLogs := []ethtypes.Log{
+		makeLog(knownMinipool, "StatusUpdated", nil, uint8(2), time),
+		makeLog(newMinipool, "StatusUpdated", nil, uint8(1), time),
+	}
+
+	state := &NetworkState{
+		NodeDetails: []NativeNodeDetails{
+			{NodeAddress: knownNode, FeeDistributorAddress: common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")},
+			{NodeAddress: otherNode, FeeDistributorAddress: distributor},
+		},
+		MinipoolDetails: []NativeMinipoolDetails{{MinipoolAddress: knownMinipool, NodeAddress: knownNode}},
+	}
+	state.updateIndices()
+	distributorOwners := map[common.Address]common.Address{}
+	for _, node := range state.NodeDetails {
+		distributorOwners[node.FeeDistributorAddress] = node.NodeAddress
+	}
+	targets := &stateUpdateTargets{
+		nodes:              map[common.Address]bool{},
+		minipools:          map[common.Address]bool{},
+		destroyedMinipools: map[common.Address]bool{},
+	}
+	if err := state.addNetworkLogTargets(targets, networkLogs, events, distributorOwners); err != nil {
+		t.Fatalf("error reading network logs: %v", err)
+	}
+	if err := state.addMinipoolLogTargets(targets, minipoolLogs, events, distributorOwners); err != nil {
+		t.Fatalf("error reading minipool logs: %v", err)
+	}
+	targets.removeShortLivedMinipools()
+
+	// The known node was named by an event, and the other node through its distributor and the legacy event
+	checkSet := func(kind string, actual map[common.Address]bool, expected []common.Address) {
+		if len(actual) != len(expected) {
+			t.Errorf("expected %d %s but got %d: %v", len(expected), kind, len(actual), actual)
+		}
+		for _, address := range expected {
+			if !actual[address] {
+				t.Errorf("expected %s in the %s", address.Hex(), kind)
+			}
+		}
+	}
+	checkSet("nodes", targets.nodes, []common.Address{knownNode, otherNode})
+	checkSet("minipools", targets.minipools, []common.Address{knownMinipool})
+	checkSet("destroyed minipools", targets.destroyedMinipools, []common.Address{shortLivedMinipool})
+
+	if len(targets.newNodes) != 1 || targets.newNodes[0] != newNode {
+		t.Errorf("expected new node %s but got %v", newNode.Hex(), targets.newNodes)
+	}
+	if len(targets.newMinipools) != 1 || targets.newMinipools[0] != newMinipool {
+		t.Errorf("expected new minipool %s but got %v", newMinipool.Hex(), targets.newMinipools)
+	}
+}
+
+func TestGetEventAddressArgs(t *testing.T) {
+	parsed, err := abi.JSON(strings.NewReader(updateTestAbi))
+	if err != nil {
+		t.Fatalf("error parsing ABI: %v", err)
+	}
+	event := parsed.Events["Transfer"]
+	from := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	to := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	data, err := event.Inputs.NonIndexed().Pack(to, big.NewInt(5))
+	if err != nil {
+		t.Fatalf("error packing event: %v", err)
+	}
+
+	args, err := getEventAddressArgs(event, ethtypes.Log{Topics: []common.Hash{event.ID, common.BytesToHash(from.Bytes())}, Data: data})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(args) != 2 || args["from"] != from || args["to"] != to {
+		t.Errorf("unexpected arguments %v", args)
+	}
+
+	// Missing indexed topics are an error
+	if _, err := getEventAddressArgs(event, ethtypes.Log{Topics: []common.Hash{event.ID}, Data: data}); err == nil {
+		t.Error("expected an error for a log without its indexed topics")
+	}
+}
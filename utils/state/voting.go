@@ -0,0 +1,71 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/utils/multicall"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	nodeVotingPowerBatchSize int = 500
+)
+
+// Gets the voting power of a node at the contracts' block using the efficient multicall contract
+func GetNodeVotingPower(rp *rocketpool.RocketPool, contracts *NetworkContracts, nodeAddress common.Address) (*big.Int, error) {
+	opts := &bind.CallOpts{
+		BlockNumber: contracts.ElBlockNumber,
+	}
+
+	powers, err := getNodeVotingPowerFast(rp, contracts, []common.Address{nodeAddress}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return powers[0], nil
+}
+
+// Gets the voting power of each of the provided nodes using the multicaller
+func getNodeVotingPowerFast(rp *rocketpool.RocketPool, contracts *NetworkContracts, addresses []common.Address, opts *bind.CallOpts) ([]*big.Int, error) {
+	blockNumber := uint32(contracts.ElBlockNumber.Uint64())
+
+	// Sync
+	var wg errgroup.Group
+	wg.SetLimit(threadLimit)
+	count := len(addresses)
+	powers := make([]*big.Int, count)
+
+	// Run the getters in batches
+	for i := 0; i < count; i += nodeVotingPowerBatchSize {
+		i := i
+		max := i + nodeVotingPowerBatchSize
+		if max > count {
+			max = count
+		}
+
+		wg.Go(func() error {
+			var err error
+			mc, err := multicall.NewMultiCaller(rp.Client, contracts.Multicaller.ContractAddress)
+			if err != nil {
+				return err
+			}
+			for j := i; j < max; j++ {
+				mc.AddCall(contracts.RocketNetworkVoting, &powers[j], "getVotingPower", addresses[j], blockNumber)
+			}
+			_, err = mc.FlexibleCall(true, opts)
+			if err != nil {
+				return fmt.Errorf("error executing multicall: %w", err)
+			}
+			return nil
+		})
+	}
+
+	if err := wg.Wait(); err != nil {
+		return nil, fmt.Errorf("error getting node voting power: %w", err)
+	}
+
+	return powers, nil
+}
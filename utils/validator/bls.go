@@ -0,0 +1,32 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/rocket-pool/rocketpool-go/types"
+	blst "github.com/supranational/blst/bindings/go"
+)
+
+// The domain separation tag used by Beacon chain signatures
+const blsSignatureDst = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+
+// Verify a BLS signature over a message (the 32-byte signing root) for a validator pubkey
+func VerifySignature(pubkey types.ValidatorPubkey, message []byte, signature types.ValidatorSignature) (bool, error) {
+	pubkeyPoint := new(blst.P1Affine).Uncompress(pubkey.Bytes())
+	if pubkeyPoint == nil {
+		return false, fmt.Errorf("invalid pubkey: not a compressed point on G1")
+	}
+	if !pubkeyPoint.KeyValidate() {
+		return false, fmt.Errorf("invalid pubkey: not a point in the G1 subgroup")
+	}
+	signaturePoint := new(blst.P2Affine).Uncompress(signature.Bytes())
+	if signaturePoint == nil {
+		return false, fmt.Errorf("invalid signature: not a compressed point on G2")
+	}
+	if !signaturePoint.SigValidate(false) {
+		return false, fmt.Errorf("invalid signature: not a point in the G2 subgroup")
+	}
+
+	// The pubkey and signature have already been validated
+	return signaturePoint.Verify(false, pubkeyPoint, false, message, []byte(blsSignatureDst)), nil
+}
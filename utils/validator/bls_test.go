@@ -0,0 +1,134 @@
+package validator
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/rocket-pool/rocketpool-go/types"
+	blst "github.com/supranational/blst/bindings/go"
+)
+
+// The BLS12381G2_XMD:SHA-256_SSWU_RO_ vectors from RFC 9380, appendix J.10.1, which signatures are built on
+func TestHashToG2(t *testing.T) {
+	dst := []byte("QUUX-V01-CS02-with-BLS12381G2_XMD:SHA-256_SSWU_RO_")
+	tests := []struct {
+		message string
+		x       [2]string // c0, c1
+		y       [2]string
+	}{
+		{
+			message: "",
+			x: [2]string{
+				"0141ebfbdca40eb85b87142e130ab689c673cf60f1a3e98d69335266f30d9b8d4ac44c1038e9dcdd5393faf5c41fb78a",
+				"05cb8437535e20ecffaef7752baddf98034139c38452458baeefab379ba13dff5bf5dd71b72418717047f5b0f37da03d",
+			},
+			y: [2]string{
+				"0503921d7f6a12805e72940b963c0cf3471c7b2a524950ca195d11062ee75ec076daf2d4bc358c4b190c0c98064fdd92",
+				"12424ac32561493f3fe3c260708a12b7c620e7be00099a974e259ddc7d1f6395c3c811cdd19f1e8dbf3e9ecfdcbab8d6",
+			},
+		},
+		{
+			message: "abc",
+			x: [2]string{
+				"02c2d18e033b960562aae3cab37a27ce00d80ccd5ba4b7fe0e7a210245129dbec7780ccc7954725f4168aff2787776e6",
+				"139cddbccdc5e91b9623efd38c49f81a6f83f175e80b06fc374de9eb4b41dfe4ca3a230ed250fbe3a2acf73a41177fd8",
+			},
+			y: [2]string{
+				"1787327b68159716a37440985269cf584bcb1e621d3a7202be6ea05c4cfe244aeb197642555a0645fb87bf7466b2ba48",
+				"00aa65dae3c8d732d10ecd2c50f8a1baf3001578f71c694e03866e9f3d49ac1e1ce70dd94a733534f106d4cec0eddd16",
+			},
+		},
+		{
+			message: "abcdef0123456789",
+			x: [2]string{
+				"121982811d2491fde9ba7ed31ef9ca474f0e1501297f68c298e9f4c0028add35aea8bb83d53c08cfc007c1e005723cd0",
+				"190d119345b94fbd15497bcba94ecf7db2cbfd1e1fe7da034d26cbba169fb3968288b3fafb265f9ebd380512a71c3f2c",
+			},
+			y: [2]string{
+				"05571a0f8d3c08d094576981f4a3b8eda0a8e771fcdcc8ecceaf1356a6acf17574518acb506e435b639353c2e14827c8",
+				"0bb5e7572275c567462d91807de765611490205a941a5a6af3b1691bfe596c31225d3aabdf15faff860cb4ef17c7c3be",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		point := blst.HashToG2([]byte(test.message), dst).ToAffine()
+
+		// Points are serialized as x.c1 || x.c0 || y.c1 || y.c0
+		expected := common.FromHex(test.x[1] + test.x[0] + test.y[1] + test.y[0])
+		if actual := point.Serialize(); !bytes.Equal(actual, expected) {
+			t.Errorf("unexpected point for message '%s': %x", test.message, actual)
+		}
+	}
+}
+
+func TestGetDepositDomain(t *testing.T) {
+	domain, err := GetDepositDomain(MainnetGenesisForkVersion)
+	if err != nil {
+		t.Fatalf("error getting deposit domain: %v", err)
+	}
+	if expected := common.HexToHash("0x03000000f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9"); domain != expected {
+		t.Errorf("expected mainnet deposit domain %s, got %s", expected.Hex(), domain.Hex())
+	}
+}
+
+func TestVerifyDepositSignature(t *testing.T) {
+	// The private key used by the consensus spec BLS tests, and its pubkey
+	secretKey := new(blst.SecretKey).Deserialize(common.FromHex("0x263dbd792f5b1be47ed85f8938c0f29586af0d3ac7b977f21c278fe1462040e3"))
+	pubkey := types.BytesToValidatorPubkey(new(blst.P1Affine).From(secretKey).Compress())
+	if expected := "a491d1b0ecd9bb917989f0e74f0dea0422eac4a873e5e2644f368dffb9a6e20fd6e10c1b77654d067c0618f6e5a7f79a"; pubkey.Hex() != expected {
+		t.Fatalf("expected pubkey %s, got %s", expected, pubkey.Hex())
+	}
+
+	// Sign a 1 ETH prestake deposit for a minipool on mainnet
+	sign := func(data DepositData, forkVersion [4]byte, secretKey *blst.SecretKey) types.ValidatorSignature {
+		signingRoot, err := GetDepositSigningRoot(data.Pubkey, data.WithdrawalCredentials, data.Amount, forkVersion)
+		if err != nil {
+			t.Fatalf("error getting signing root: %v", err)
+		}
+		return types.BytesToValidatorSignature(new(blst.P2Affine).Sign(secretKey, signingRoot.Bytes(), []byte(blsSignatureDst)).Compress())
+	}
+	deposit := DepositData{
+		Pubkey:                pubkey,
+		WithdrawalCredentials: GetMinipoolWithdrawalCredentials(common.HexToAddress("0x2222222222222222222222222222222222222222")),
+		Amount:                1000000000,
+	}
+	deposit.Signature = sign(deposit, MainnetGenesisForkVersion, secretKey)
+
+	wrongAmount := deposit
+	wrongAmount.Amount = 32000000000
+	wrongKey := deposit
+	wrongKey.Signature = sign(deposit, MainnetGenesisForkVersion, new(blst.SecretKey).Deserialize(common.LeftPadBytes([]byte{42}, 32)))
+	invalidPubkey := deposit
+	invalidPubkey.Pubkey = types.BytesToValidatorPubkey(make([]byte, types.ValidatorPubkeyLength))
+
+	tests := []struct {
+		name        string
+		data        DepositData
+		forkVersion [4]byte
+		valid       bool
+	}{
+		{name: "mainnet", data: deposit, forkVersion: MainnetGenesisForkVersion, valid: true},
+		{name: "wrong fork version", data: deposit, forkVersion: PraterGenesisForkVersion},
+		{name: "wrong amount", data: wrongAmount, forkVersion: MainnetGenesisForkVersion},
+		{name: "signed by another key", data: wrongKey, forkVersion: MainnetGenesisForkVersion},
+		{name: "pubkey isn't a point", data: invalidPubkey, forkVersion: MainnetGenesisForkVersion},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := VerifyDepositSignature(test.data, test.forkVersion)
+			if test.valid {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			var signatureErr *InvalidDepositSignatureError
+			if !errors.As(err, &signatureErr) {
+				t.Errorf("expected an InvalidDepositSignatureError, got %v", err)
+			}
+		})
+	}
+}
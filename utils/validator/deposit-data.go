@@ -0,0 +1,203 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/prysmaticlabs/go-ssz"
+	"github.com/rocket-pool/rocketpool-go/minipool"
+	"github.com/rocket-pool/rocketpool-go/rocketpool"
+	"github.com/rocket-pool/rocketpool-go/types"
+)
+
+// The domain type of Beacon deposits
+var depositDomainType = [4]byte{0x03, 0x00, 0x00, 0x00}
+
+// Genesis fork versions, which deposits are always signed with
+var (
+	MainnetGenesisForkVersion = [4]byte{0x00, 0x00, 0x00, 0x00}
+	PraterGenesisForkVersion  = [4]byte{0x00, 0x00, 0x10, 0x20}
+	HoleskyGenesisForkVersion = [4]byte{0x01, 0x01, 0x70, 0x00}
+)
+
+// The deposit data for a validator
+type DepositData struct {
+	Pubkey                types.ValidatorPubkey    `json:"pubkey"`
+	WithdrawalCredentials common.Hash              `json:"withdrawalCredentials"`
+	Amount                uint64                   `json:"amount"` // In gwei
+	Signature             types.ValidatorSignature `json:"signature"`
+}
+
+// The withdrawal credentials don't point to the minipool
+type WithdrawalCredentialsMismatchError struct {
+	MinipoolAddress common.Address
+	Expected        common.Hash
+	Actual          common.Hash
+}
+
+func (e *WithdrawalCredentialsMismatchError) Error() string {
+	return fmt.Sprintf("withdrawal credentials %s do not match minipool %s (expected %s)", e.Actual.Hex(), e.MinipoolAddress.Hex(), e.Expected.Hex())
+}
+
+// The deposit signature isn't valid for the pubkey and deposit message
+type InvalidDepositSignatureError struct {
+	Pubkey types.ValidatorPubkey
+	Reason string
+}
+
+func (e *InvalidDepositSignatureError) Error() string {
+	return fmt.Sprintf("invalid deposit signature for validator %s: %s", e.Pubkey.Hex(), e.Reason)
+}
+
+// The deposit data root doesn't match the deposit data
+type DepositDataRootMismatchError struct {
+	Expected common.Hash
+	Actual   common.Hash
+}
+
+func (e *DepositDataRootMismatchError) Error() string {
+	return fmt.Sprintf("deposit data root %s does not match the deposit data (expected %s)", e.Actual.Hex(), e.Expected.Hex())
+}
+
+// SSZ containers
+type depositMessage struct {
+	Pubkey                []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+}
+type depositDataContainer struct {
+	Pubkey                []byte `ssz-size:"48"`
+	WithdrawalCredentials []byte `ssz-size:"32"`
+	Amount                uint64
+	Signature             []byte `ssz-size:"96"`
+}
+type forkData struct {
+	CurrentVersion        []byte `ssz-size:"4"`
+	GenesisValidatorsRoot []byte `ssz-size:"32"`
+}
+type signingData struct {
+	ObjectRoot []byte `ssz-size:"32"`
+	Domain     []byte `ssz-size:"32"`
+}
+
+// Get the 0x01 withdrawal credentials of a minipool
+func GetMinipoolWithdrawalCredentials(minipoolAddress common.Address) common.Hash {
+	var credentials common.Hash
+	credentials[0] = 0x01
+	copy(credentials[12:], minipoolAddress.Bytes())
+	return credentials
+}
+
+// Get the signing domain for deposits with the given genesis fork version
+func GetDepositDomain(forkVersion [4]byte) (common.Hash, error) {
+	// Deposits use an empty genesis validators root so they can be signed before genesis
+	forkDataRoot, err := ssz.HashTreeRoot(forkData{
+		CurrentVersion:        forkVersion[:],
+		GenesisValidatorsRoot: make([]byte, 32),
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting fork data root: %w", err)
+	}
+	var domain common.Hash
+	copy(domain[:4], depositDomainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain, nil
+}
+
+// Get the root that a deposit's signature is over
+func GetDepositSigningRoot(pubkey types.ValidatorPubkey, withdrawalCredentials common.Hash, amount uint64, forkVersion [4]byte) (common.Hash, error) {
+	messageRoot, err := ssz.HashTreeRoot(depositMessage{
+		Pubkey:                pubkey.Bytes(),
+		WithdrawalCredentials: withdrawalCredentials.Bytes(),
+		Amount:                amount,
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting deposit message root: %w", err)
+	}
+	domain, err := GetDepositDomain(forkVersion)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signingRoot, err := ssz.HashTreeRoot(signingData{
+		ObjectRoot: messageRoot[:],
+		Domain:     domain.Bytes(),
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting deposit signing root: %w", err)
+	}
+	return signingRoot, nil
+}
+
+// Get the deposit data root, which is passed to the deposit contract alongside the deposit data
+func GetDepositDataRoot(data DepositData) (common.Hash, error) {
+	root, err := ssz.HashTreeRoot(depositDataContainer{
+		Pubkey:                data.Pubkey.Bytes(),
+		WithdrawalCredentials: data.WithdrawalCredentials.Bytes(),
+		Amount:                data.Amount,
+		Signature:             data.Signature.Bytes(),
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("error getting deposit data root: %w", err)
+	}
+	return root, nil
+}
+
+// Verify the deposit signature for the given genesis fork version
+func VerifyDepositSignature(data DepositData, forkVersion [4]byte) error {
+	signingRoot, err := GetDepositSigningRoot(data.Pubkey, data.WithdrawalCredentials, data.Amount, forkVersion)
+	if err != nil {
+		return err
+	}
+	valid, err := VerifySignature(data.Pubkey, signingRoot.Bytes(), data.Signature)
+	if err != nil {
+		return &InvalidDepositSignatureError{Pubkey: data.Pubkey, Reason: err.Error()}
+	}
+	if !valid {
+		return &InvalidDepositSignatureError{Pubkey: data.Pubkey, Reason: fmt.Sprintf("signature does not match the deposit message for fork version %x", forkVersion)}
+	}
+	return nil
+}
+
+// Validate deposit data for a minipool, returning its deposit data root.
+// If expectedRoot isn't nil, it's checked against the calculated root.
+func ValidateDepositData(data DepositData, minipoolAddress common.Address, forkVersion [4]byte, expectedRoot *common.Hash) (common.Hash, error) {
+	credentials := GetMinipoolWithdrawalCredentials(minipoolAddress)
+	if data.WithdrawalCredentials != credentials {
+		return common.Hash{}, &WithdrawalCredentialsMismatchError{
+			MinipoolAddress: minipoolAddress,
+			Expected:        credentials,
+			Actual:          data.WithdrawalCredentials,
+		}
+	}
+	if err := VerifyDepositSignature(data, forkVersion); err != nil {
+		return common.Hash{}, err
+	}
+	root, err := GetDepositDataRoot(data)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if expectedRoot != nil && *expectedRoot != root {
+		return common.Hash{}, &DepositDataRootMismatchError{
+			Expected: root,
+			Actual:   *expectedRoot,
+		}
+	}
+	return root, nil
+}
+
+// Validate deposit data for a minipool, also checking the withdrawal credentials against the ones reported by the minipool manager
+func ValidateMinipoolDepositData(rp *rocketpool.RocketPool, data DepositData, minipoolAddress common.Address, forkVersion [4]byte, expectedRoot *common.Hash, opts *bind.CallOpts) (common.Hash, error) {
+	credentials, err := minipool.GetMinipoolWithdrawalCredentials(rp, minipoolAddress, opts)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if data.WithdrawalCredentials != credentials {
+		return common.Hash{}, &WithdrawalCredentialsMismatchError{
+			MinipoolAddress: minipoolAddress,
+			Expected:        credentials,
+			Actual:          data.WithdrawalCredentials,
+		}
+	}
+	return ValidateDepositData(data, minipoolAddress, forkVersion, expectedRoot)
+}
@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// The default interval between progress reports during a vanity salt search
+const DefaultVanityProgressInterval = time.Second
+
+// The parameters of a search for a salt that gives a minipool address a recognisable prefix and / or suffix
+type VanitySaltSearch struct {
+	FactoryAddress common.Address
+	InitCodeHash   common.Hash
+	NodeAddress    common.Address
+
+	// Hex characters the address must start and / or end with (case-insensitive, with or without 0x)
+	Prefix string
+	Suffix string
+
+	// The first salt to try; defaults to 0
+	StartSalt *big.Int
+
+	// The number of salts to check at once; defaults to the number of CPUs
+	Threads int
+
+	// How often to report progress; defaults to DefaultVanityProgressInterval
+	ProgressInterval time.Duration
+}
+
+// A salt found by a vanity salt search
+type VanitySaltResult struct {
+	Salt    *big.Int
+	Address common.Address
+	Checked uint64 // The number of salts checked across all threads
+}
+
+// Search for a salt that gives the node's next minipool an address with the requested prefix and / or suffix.
+// The search runs until a match is found or the context is cancelled. If progress isn't nil, it's called periodically
+// with the number of salts checked so far.
+func FindVanitySalt(ctx context.Context, search VanitySaltSearch, progress func(checked uint64)) (VanitySaltResult, error) {
+	prefix := strings.ToLower(strings.TrimPrefix(search.Prefix, "0x"))
+	suffix := strings.ToLower(strings.TrimPrefix(search.Suffix, "0x"))
+	if prefix == "" && suffix == "" {
+		return VanitySaltResult{}, fmt.Errorf("a prefix or suffix is required")
+	}
+	for _, part := range []string{prefix, suffix} {
+		if len(part) > common.AddressLength*2 {
+			return VanitySaltResult{}, fmt.Errorf("%s is longer than an address", part)
+		}
+		if strings.Trim(part, "0123456789abcdef") != "" {
+			return VanitySaltResult{}, fmt.Errorf("%s is not a hex string", part)
+		}
+	}
+	if len(prefix)+len(suffix) > common.AddressLength*2 {
+		return VanitySaltResult{}, fmt.Errorf("the prefix and suffix are longer than an address")
+	}
+
+	// Defaults
+	startSalt := search.StartSalt
+	if startSalt == nil {
+		startSalt = big.NewInt(0)
+	}
+	if startSalt.Sign() < 0 {
+		return VanitySaltResult{}, fmt.Errorf("start salt %s is negative", startSalt.String())
+	}
+	threads := search.Threads
+	if threads <= 0 {
+		threads = runtime.NumCPU()
+	}
+	progressInterval := search.ProgressInterval
+	if progressInterval <= 0 {
+		progressInterval = DefaultVanityProgressInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var checked uint64
+	var result VanitySaltResult
+	var resultLock sync.Mutex
+	var searchErr error
+	found := false
+
+	// Progress reporting
+	var progressWg sync.WaitGroup
+	if progress != nil {
+		progressWg.Add(1)
+		go func() {
+			defer progressWg.Done()
+			ticker := time.NewTicker(progressInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					progress(atomic.LoadUint64(&checked))
+				}
+			}
+		}()
+	}
+
+	// Each thread checks every nth salt after the start
+	var wg sync.WaitGroup
+	step := big.NewInt(int64(threads))
+	for i := 0; i < threads; i++ {
+		salt := big.NewInt(0).Add(startSalt, big.NewInt(int64(i)))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hexBuffer := make([]byte, common.AddressLength*2)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				address, err := GetExpectedMinipoolAddress(search.FactoryAddress, search.InitCodeHash, search.NodeAddress, salt)
+				if err != nil {
+					// The salts have run past the end of the uint256 range
+					resultLock.Lock()
+					if searchErr == nil {
+						searchErr = err
+					}
+					resultLock.Unlock()
+					cancel()
+					return
+				}
+				atomic.AddUint64(&checked, 1)
+				hex.Encode(hexBuffer, address.Bytes())
+				addressHex := string(hexBuffer)
+				if strings.HasPrefix(addressHex, prefix) && strings.HasSuffix(addressHex, suffix) {
+					resultLock.Lock()
+					if !found {
+						found = true
+						result.Salt = big.NewInt(0).Set(salt)
+						result.Address = address
+					}
+					resultLock.Unlock()
+					cancel()
+					return
+				}
+				salt.Add(salt, step)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Stop progress reporting before returning so the callback isn't called afterwards
+	cancel()
+	progressWg.Wait()
+
+	result.Checked = atomic.LoadUint64(&checked)
+	if !found {
+		if searchErr != nil {
+			return result, fmt.Errorf("vanity salt search stopped after %d salts: %w", result.Checked, searchErr)
+		}
+		return result, fmt.Errorf("vanity salt search stopped after %d salts: %w", result.Checked, ctx.Err())
+	}
+	return result, nil
+}